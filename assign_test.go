@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+// TestAssignment 测试属性与元素赋值、自增自减以及赋值表达式的值
+func TestAssignment(t *testing.T) {
+	checkValues(t, "assign.vine", []valueTest{
+		{"let c = {db: {port: 1}}\nc.db.port = 2\nc[\"db\"][\"host\"] = \"h\"\n[c.db.port, c.db.host]", []any{int64(2), "h"}},
+		{"let a = [1, 2, 3]\na[0] = 10\na[1 + 1] = 30\na", []any{int64(10), int64(2), int64(30)}},
+		{"let c = {db: {host: \"a\"}}\nlet alias = c.db\nalias.host = \"b\"\nc.db.host", "b"},
+		{"let o = {n: 0}\no.n++\n++o.n\no.n", int64(2)},
+		{"let a = [5]\na[0]--\na[0]", int64(4)},
+		{"let a = 0\nlet b = 0\na = b = 5\n[a, b]", []any{int64(5), int64(5)}},
+		{"let a = 1\n[a++, a, ++a, a]", []any{int64(1), int64(2), int64(3), int64(3)}},
+	})
+}
@@ -40,19 +40,55 @@ const (
 	NodeTypeSwitchCase
 	NodeTypeBreakStmt
 	NodeTypeContinueStmt
+	NodeTypeTemplateLiteralExpr
+	NodeTypeTemplateElement
+	NodeTypeTernaryExpr
+	NodeTypeRangeExpr
+	NodeTypeArrayPattern
+	NodeTypeObjectPattern
+	NodeTypeParameter
+	NodeTypeNamedArg
+	NodeTypeSpreadExpr
+	NodeTypeChainExpr
+	NodeTypeMatchExpr
+	NodeTypeMatchArm
+	NodeTypeTypePattern
+	NodeTypeWhileStmt
+	NodeTypeTypeDecl
+	NodeTypePinPattern
 
 	NodeTypeCommentStmt
 	NodeTypeBaseNode
 )
 
+// 扩展节点类型从 NodeTypeCustom 开始分配，与内置类型互不冲突
+const NodeTypeCustom NodeType = 1 << 15
+
+var customNodeTypes = map[NodeType]string{}
+
+// RegisterNodeType 为扩展语法分配一个新的节点类型，应在初始化阶段调用
+func RegisterNodeType(name string) NodeType {
+	t := NodeTypeCustom + NodeType(len(customNodeTypes))
+	customNodeTypes[t] = name
+	return t
+}
+
+// CustomNodeName 返回扩展节点类型注册时的名字
+func CustomNodeName(t NodeType) (string, bool) {
+	name, ok := customNodeTypes[t]
+	return name, ok
+}
+
 type Node interface {
 	NodeType() NodeType
+	NodeSpan() Span
 	String() string
 }
 
 type BaseNode struct {
 	Type  NodeType
 	Token *token.Token
+	Span  Span // 节点在源码中的范围
 }
 
 func (n *BaseNode) String() string {
@@ -63,6 +99,14 @@ func (n *BaseNode) NodeType() NodeType {
 	return n.Type
 }
 
+func (n *BaseNode) NodeSpan() Span {
+	return n.Span
+}
+
+func (n *BaseNode) SetSpan(span Span) {
+	n.Span = span
+}
+
 type Expr interface {
 	Node
 	String() string
@@ -118,6 +162,9 @@ func NewProperty(key *Literal, value Expr) *Property {
 }
 
 func (p *Property) String() string {
+	if p.Key == nil {
+		return p.Value.String()
+	}
 	return fmt.Sprintf("%s: %s", p.Key.String(), p.Value.String())
 }
 
@@ -125,10 +172,26 @@ func (p *Property) NodeType() NodeType {
 	return p.Type
 }
 
-// type TemplateElement struct {
-// 	BaseNode
-// 	Value *Literal
-// }
+// TemplateElement 模板字符串中的字面量片段
+type TemplateElement struct {
+	BaseNode
+	Value *Literal
+}
+
+func NewTemplateElement(value *Literal) *TemplateElement {
+	return &TemplateElement{
+		BaseNode: BaseNode{Type: NodeTypeTemplateElement},
+		Value:    value,
+	}
+}
+
+func (t *TemplateElement) String() string {
+	return fmt.Sprintf("TemplateElement(%q)", t.Value.Value.Value)
+}
+
+func (t *TemplateElement) NodeType() NodeType {
+	return t.Type
+}
 
 // type EmptyLineStmt struct {
 // 	BaseNode
@@ -154,6 +217,27 @@ func (l *CommentStmt) NodeType() NodeType {
 	return l.Type
 }
 
+// CommentGroup 文档注释，由声明前连续的 ## 注释行组成
+type CommentGroup struct {
+	List []token.Token
+}
+
+func NewCommentGroup(list []token.Token) *CommentGroup {
+	return &CommentGroup{List: list}
+}
+
+// Text 返回去掉注释标记后的文档内容，多行以换行符连接
+func (g *CommentGroup) Text() string {
+	if g == nil {
+		return ""
+	}
+	var lines = make([]string, len(g.List))
+	for i, tk := range g.List {
+		lines[i] = strings.TrimPrefix(tk.Value, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
 // ================================== Declarations ==================================
 
 // UseSpecifier
@@ -172,7 +256,7 @@ func NewUseSpecifier(remote, local *Literal) *UseSpecifier {
 }
 
 func (u *UseSpecifier) String() string {
-	return fmt.Sprintf("UseSpecifier(%s, %s)", u.Remote.String(), u.Local.String())
+	return fmt.Sprintf("UseSpecifier(%s, %s)", u.Remote.String(), nodeString(u.Local))
 }
 
 func (u *UseSpecifier) NodeType() NodeType {
@@ -210,6 +294,7 @@ type FunctionDecl struct {
 	ID        *Literal
 	Arguments *ArgsExpr
 	Body      *BlockStmt
+	Doc       *CommentGroup // 文档注释，可能为 nil
 }
 
 func NewFunctionDecl(id *Literal, args *ArgsExpr, body *BlockStmt) *FunctionDecl {
@@ -256,8 +341,10 @@ func (l *LambdaFunctionDecl) NodeType() NodeType {
 type VariableDecl struct {
 	BaseNode
 	Name    Literal
+	Pattern Expr // 解构声明时为 ArrayPattern 或 ObjectPattern，此时 Name 为空
 	Value   Expr
 	IsConst bool
+	Doc     *CommentGroup // 文档注释，可能为 nil
 }
 
 func NewVariableDecl(name Literal, value Expr, isConst bool) *VariableDecl {
@@ -276,22 +363,301 @@ func (v *VariableDecl) String() string {
 	} else {
 		prefix = "let"
 	}
+	if v.Pattern != nil {
+		return fmt.Sprintf("%s %s = %s", prefix, v.Pattern.String(), v.Value)
+	}
 	return fmt.Sprintf("%s %s = %s", prefix, v.Name.String(), v.Value)
 }
 
+// ================================== Patterns ==================================
+
+// ArrayPattern 数组解构 [a, b, [c, d]]，元素为 Literal 或嵌套的解构模式
+type ArrayPattern struct {
+	BaseNode
+	Elements []Expr
+}
+
+func NewArrayPattern(elements []Expr) *ArrayPattern {
+	return &ArrayPattern{
+		BaseNode: BaseNode{Type: NodeTypeArrayPattern},
+		Elements: elements,
+	}
+}
+
+func (a *ArrayPattern) String() string {
+	var elements = make([]string, len(a.Elements))
+	for i, el := range a.Elements {
+		elements[i] = el.String()
+	}
+	return fmt.Sprintf("ArrayPattern(%s)", strings.Join(elements, ", "))
+}
+
+func (a *ArrayPattern) NodeType() NodeType {
+	return a.Type
+}
+
+// ObjectPattern 对象解构 {name, age as years, pos: {x, y}}
+// Property.Key 为属性名，Property.Value 为绑定目标
+type ObjectPattern struct {
+	BaseNode
+	Properties []*Property
+}
+
+func NewObjectPattern(properties []*Property) *ObjectPattern {
+	return &ObjectPattern{
+		BaseNode:   BaseNode{Type: NodeTypeObjectPattern},
+		Properties: properties,
+	}
+}
+
+func (o *ObjectPattern) String() string {
+	var props = make([]string, len(o.Properties))
+	for i, prop := range o.Properties {
+		props[i] = prop.String()
+	}
+	return fmt.Sprintf("ObjectPattern(%s)", strings.Join(props, ", "))
+}
+
+func (o *ObjectPattern) NodeType() NodeType {
+	return o.Type
+}
+
+// Parameter 函数参数：标识符或解构模式，可带默认值；Rest 为 ...rest 剩余参数
+type Parameter struct {
+	BaseNode
+	Name    Expr
+	Default Expr
+	Rest    bool
+}
+
+func NewParameter(name Expr, def Expr, rest bool) *Parameter {
+	return &Parameter{
+		BaseNode: BaseNode{Type: NodeTypeParameter},
+		Name:     name,
+		Default:  def,
+		Rest:     rest,
+	}
+}
+
+func (p *Parameter) String() string {
+	if p.Rest {
+		return fmt.Sprintf("Parameter(...%s)", p.Name.String())
+	}
+	if p.Default != nil {
+		return fmt.Sprintf("Parameter(%s = %s)", p.Name.String(), p.Default.String())
+	}
+	return fmt.Sprintf("Parameter(%s)", p.Name.String())
+}
+
+func (p *Parameter) NodeType() NodeType {
+	return p.Type
+}
+
+// NamedArg 调用时的具名参数 f(a: 1)
+type NamedArg struct {
+	BaseNode
+	Name  *Literal
+	Value Expr
+}
+
+func NewNamedArg(name *Literal, value Expr) *NamedArg {
+	return &NamedArg{
+		BaseNode: BaseNode{Type: NodeTypeNamedArg},
+		Name:     name,
+		Value:    value,
+	}
+}
+
+func (n *NamedArg) String() string {
+	return fmt.Sprintf("NamedArg(%s: %s)", n.Name.String(), n.Value.String())
+}
+
+func (n *NamedArg) NodeType() NodeType {
+	return n.Type
+}
+
+// SpreadExpr 展开表达式 ...value，用于数组、对象与调用参数
+type SpreadExpr struct {
+	BaseNode
+	Value Expr
+}
+
+func NewSpreadExpr(value Expr) *SpreadExpr {
+	return &SpreadExpr{
+		BaseNode: BaseNode{Type: NodeTypeSpreadExpr},
+		Value:    value,
+	}
+}
+
+func (s *SpreadExpr) String() string {
+	return fmt.Sprintf("SpreadExpr(%s)", s.Value.String())
+}
+
+func (s *SpreadExpr) NodeType() NodeType {
+	return s.Type
+}
+
+// ChainExpr 包含可选链的成员访问或调用链，链中任一可选节点遇到 nil 时整个链的值为 nil
+type ChainExpr struct {
+	BaseNode
+	Expr Expr
+}
+
+func NewChainExpr(expr Expr) *ChainExpr {
+	return &ChainExpr{
+		BaseNode: BaseNode{Type: NodeTypeChainExpr},
+		Expr:     expr,
+	}
+}
+
+func (c *ChainExpr) String() string {
+	return fmt.Sprintf("ChainExpr(%s)", c.Expr.String())
+}
+
+func (c *ChainExpr) NodeType() NodeType {
+	return c.Type
+}
+
+// MatchExpr 模式匹配表达式，值为第一个匹配分支的结果
+type MatchExpr struct {
+	BaseNode
+	Subject Expr
+	Arms    []*MatchArm
+}
+
+func NewMatchExpr(subject Expr, arms []*MatchArm) *MatchExpr {
+	return &MatchExpr{
+		BaseNode: BaseNode{Type: NodeTypeMatchExpr},
+		Subject:  subject,
+		Arms:     arms,
+	}
+}
+
+func (m *MatchExpr) String() string {
+	var arms = make([]string, len(m.Arms))
+	for i, arm := range m.Arms {
+		arms[i] = arm.String()
+	}
+	return fmt.Sprintf("MatchExpr(%s, [%s])", m.Subject.String(), strings.Join(arms, ", "))
+}
+
+func (m *MatchExpr) NodeType() NodeType {
+	return m.Type
+}
+
+// MatchArm 匹配分支：多个候选模式，可选的 if 守卫
+type MatchArm struct {
+	BaseNode
+	Patterns []Expr
+	Guard    Expr
+	Body     *BlockStmt
+}
+
+func NewMatchArm(patterns []Expr, guard Expr, body *BlockStmt) *MatchArm {
+	return &MatchArm{
+		BaseNode: BaseNode{Type: NodeTypeMatchArm},
+		Patterns: patterns,
+		Guard:    guard,
+		Body:     body,
+	}
+}
+
+func (m *MatchArm) String() string {
+	var patterns = make([]string, len(m.Patterns))
+	for i, pattern := range m.Patterns {
+		patterns[i] = pattern.String()
+	}
+	if m.Guard != nil {
+		return fmt.Sprintf("MatchArm([%s] if %s, %s)", strings.Join(patterns, ", "), m.Guard.String(), m.Body.String())
+	}
+	return fmt.Sprintf("MatchArm([%s], %s)", strings.Join(patterns, ", "), m.Body.String())
+}
+
+func (m *MatchArm) NodeType() NodeType {
+	return m.Type
+}
+
+// TypePattern 类型模式 typeof int
+type TypePattern struct {
+	BaseNode
+	Name *Literal
+}
+
+func NewTypePattern(name *Literal) *TypePattern {
+	return &TypePattern{
+		BaseNode: BaseNode{Type: NodeTypeTypePattern},
+		Name:     name,
+	}
+}
+
+func (t *TypePattern) String() string {
+	return fmt.Sprintf("TypePattern(%s)", t.Name.Value.Value)
+}
+
+func (t *TypePattern) NodeType() NodeType {
+	return t.Type
+}
+
+// PinPattern 值模式 ^name，与变量当前的值比较而不是绑定新的变量
+type PinPattern struct {
+	BaseNode
+	Name *Literal
+}
+
+func NewPinPattern(name *Literal) *PinPattern {
+	return &PinPattern{
+		BaseNode: BaseNode{Type: NodeTypePinPattern},
+		Name:     name,
+	}
+}
+
+func (p *PinPattern) String() string {
+	return fmt.Sprintf("PinPattern(%s)", p.Name.Value.Value)
+}
+
+func (p *PinPattern) NodeType() NodeType {
+	return p.Type
+}
+
 func (v *VariableDecl) NodeType() NodeType {
 	return v.Type
 }
 
 // ================================== Expressions ==================================
 
-// // RangeExpr
-// type RangeExpr struct {
-// 	BaseNode
-// 	Start Expr
-// 	End   Expr
-// 	Step  token.Token
-// }
+// RangeExpr start..end 或 start..<end，可带 step
+type RangeExpr struct {
+	BaseNode
+	Start     Expr
+	End       Expr
+	Step      Expr // 可选
+	Inclusive bool // 是否包含 End
+}
+
+func NewRangeExpr(start, end, step Expr, inclusive bool) *RangeExpr {
+	return &RangeExpr{
+		BaseNode:  BaseNode{Type: NodeTypeRangeExpr},
+		Start:     start,
+		End:       end,
+		Step:      step,
+		Inclusive: inclusive,
+	}
+}
+
+func (r *RangeExpr) String() string {
+	op := ".."
+	if !r.Inclusive {
+		op = "..<"
+	}
+	if r.Step == nil {
+		return fmt.Sprintf("RangeExpr(%s%s%s)", r.Start.String(), op, r.End.String())
+	}
+	return fmt.Sprintf("RangeExpr(%s%s%s step %s)", r.Start.String(), op, r.End.String(), r.Step.String())
+}
+
+func (r *RangeExpr) NodeType() NodeType {
+	return r.Type
+}
 
 // UnaryExpr
 type UnaryExpr struct {
@@ -374,8 +740,9 @@ func (a *ArgsExpr) String() string {
 // CallExpr
 type CallExpr struct {
 	BaseNode
-	Callee Expr
-	Args   ArgsExpr
+	Callee   Expr
+	Args     ArgsExpr
+	Optional bool // 是否为可选调用 xxx?.()
 }
 
 func NewCallExpr(callee Expr, args ArgsExpr) *CallExpr {
@@ -418,13 +785,30 @@ func (a *AssignmentExpr) NodeType() NodeType {
 	return a.Type
 }
 
-// // TernaryExpr (Type: TernayExpression)
-// type TernaryExpr struct {
-// 	BaseNode
-// 	Condition  Expr
-// 	Consequent Expr
-// 	Alternate  Expr
-// }
+// TernaryExpr cond ? a : b
+type TernaryExpr struct {
+	BaseNode
+	Condition  Expr
+	Consequent Expr
+	Alternate  Expr
+}
+
+func NewTernaryExpr(condition, consequent, alternate Expr) *TernaryExpr {
+	return &TernaryExpr{
+		BaseNode:   BaseNode{Type: NodeTypeTernaryExpr},
+		Condition:  condition,
+		Consequent: consequent,
+		Alternate:  alternate,
+	}
+}
+
+func (t *TernaryExpr) String() string {
+	return fmt.Sprintf("TernaryExpr(%s ? %s : %s)", t.Condition.String(), t.Consequent.String(), t.Alternate.String())
+}
+
+func (t *TernaryExpr) NodeType() NodeType {
+	return t.Type
+}
 
 // ObjectExpr
 type ObjectExpr struct {
@@ -482,6 +866,7 @@ type MemberExpr struct {
 	Object   Expr
 	Property Expr
 	Computed bool // 是否为计算属性 xxx[xxx]
+	Optional bool // 是否为可选链 xxx?.xxx
 }
 
 func NewMemberExpr(object, property Expr, computed bool) *MemberExpr {
@@ -529,11 +914,30 @@ func (b *BinaryExpr) NodeType() NodeType {
 	return b.Type
 }
 
-// // TemplateLiteralExpr
-// type TemplateLiteralExpr struct {
-// 	BaseNode
-// 	Quotes []Node // TemplateElement | Expr
-// }
+// TemplateLiteralExpr
+type TemplateLiteralExpr struct {
+	BaseNode
+	Quotes []Node // TemplateElement | Expr
+}
+
+func NewTemplateLiteralExpr(quotes []Node) *TemplateLiteralExpr {
+	return &TemplateLiteralExpr{
+		BaseNode: BaseNode{Type: NodeTypeTemplateLiteralExpr},
+		Quotes:   quotes,
+	}
+}
+
+func (t *TemplateLiteralExpr) String() string {
+	var quotes = make([]string, len(t.Quotes))
+	for i, q := range t.Quotes {
+		quotes[i] = q.String()
+	}
+	return fmt.Sprintf("TemplateLiteralExpr(%s)", strings.Join(quotes, ", "))
+}
+
+func (t *TemplateLiteralExpr) NodeType() NodeType {
+	return t.Type
+}
 
 // // IterableExpr (在 NodeType 中存在但未定义接口，补充定义)
 // type IterableExpr struct {
@@ -549,6 +953,7 @@ type ExposeStmt struct {
 	Decl  Stmt
 	Name  *Literal
 	Value Expr
+	Doc   *CommentGroup // 文档注释，可能为 nil
 }
 
 func NewExposeStmt(decl Stmt, name *Literal, value Expr) *ExposeStmt {
@@ -561,7 +966,7 @@ func NewExposeStmt(decl Stmt, name *Literal, value Expr) *ExposeStmt {
 }
 
 func (e *ExposeStmt) String() string {
-	return fmt.Sprintf("ExposeStmt(%s, %s, %s)", e.Decl.String(), e.Name.String(), e.Value.String())
+	return fmt.Sprintf("ExposeStmt(%s, %s, %s)", nodeString(e.Decl), nodeString(e.Name), nodeString(e.Value))
 }
 
 func (e *ExposeStmt) NodeType() NodeType {
@@ -607,7 +1012,7 @@ func NewReturnStmt(value Expr) *ReturnStmt {
 }
 
 func (r *ReturnStmt) String() string {
-	return fmt.Sprintf("ReturnStmt(%s)", r.Value.String())
+	return fmt.Sprintf("ReturnStmt(%s)", nodeString(r.Value))
 }
 
 func (r *ReturnStmt) NodeType() NodeType {
@@ -666,7 +1071,7 @@ func NewIfStmt(test Expr, consequent *BlockStmt, alternate Stmt) *IfStmt {
 }
 
 func (ifs *IfStmt) String() string {
-	return fmt.Sprintf("IfStmt(%s, %s, %s)", ifs.Test.String(), ifs.Consequent.String(), ifs.Alternate.String())
+	return fmt.Sprintf("IfStmt(%s, %s, %s)", ifs.Test.String(), ifs.Consequent.String(), nodeString(ifs.Alternate))
 }
 
 func (ifs *IfStmt) NodeType() NodeType {
@@ -676,11 +1081,13 @@ func (ifs *IfStmt) NodeType() NodeType {
 // ForStmt
 type ForStmt struct {
 	BaseNode
+	Key    Expr // for key, value in ... 中的 key，可选
 	Init   Expr
 	Value  Expr
 	Update Expr
 	Range  Expr
 	Body   BlockStmt
+	Label  *Literal // 循环标签，可选
 }
 
 func NewForStmt(init, value, update, range_ Expr, body BlockStmt) *ForStmt {
@@ -695,13 +1102,66 @@ func NewForStmt(init, value, update, range_ Expr, body BlockStmt) *ForStmt {
 }
 
 func (ifs *ForStmt) String() string {
-	return fmt.Sprintf("ForStmt(%s, %s, %s, %s, %s)", ifs.Init.String(), ifs.Value.String(), ifs.Update.String(), ifs.Range, ifs.Body.String())
+	return fmt.Sprintf("ForStmt(%s, %s, %s, %s, %s)", ifs.Init.String(), nodeString(ifs.Value), nodeString(ifs.Update), nodeString(ifs.Range), ifs.Body.String())
 }
 
 func (ifs *ForStmt) NodeType() NodeType {
 	return ifs.Type
 }
 
+// WhileStmt while cond: ... end，Test 为 nil 时为无限循环 loop: ... end
+type WhileStmt struct {
+	BaseNode
+	Test  Expr
+	Body  BlockStmt
+	Label *Literal // 循环标签，可选
+}
+
+func NewWhileStmt(test Expr, body BlockStmt) *WhileStmt {
+	return &WhileStmt{
+		BaseNode: BaseNode{Type: NodeTypeWhileStmt},
+		Test:     test,
+		Body:     body,
+	}
+}
+
+func (w *WhileStmt) String() string {
+	if w.Test == nil {
+		return fmt.Sprintf("WhileStmt(loop, %s)", w.Body.String())
+	}
+	return fmt.Sprintf("WhileStmt(%s, %s)", w.Test.String(), w.Body.String())
+}
+
+func (w *WhileStmt) NodeType() NodeType {
+	return w.Type
+}
+
+// TypeDecl type Name(Parent): ... end，Body 中只有字段声明、方法与注释
+type TypeDecl struct {
+	BaseNode
+	ID     *Literal
+	Parent Expr // 父类型，可选
+	Body   *BlockStmt
+	Doc    *CommentGroup // 文档注释，可能为 nil
+}
+
+func NewTypeDecl(id *Literal, parent Expr, body *BlockStmt) *TypeDecl {
+	return &TypeDecl{
+		BaseNode: BaseNode{Type: NodeTypeTypeDecl},
+		ID:       id,
+		Parent:   parent,
+		Body:     body,
+	}
+}
+
+func (t *TypeDecl) String() string {
+	return fmt.Sprintf("TypeDecl(%s, %s, %s)", t.ID.String(), nodeString(t.Parent), t.Body.String())
+}
+
+func (t *TypeDecl) NodeType() NodeType {
+	return t.Type
+}
+
 type SwitchCase struct {
 	BaseNode
 	Conds     []Expr
@@ -760,11 +1220,13 @@ func (s *SwitchStmt) String() string {
 // BreakStmt
 type BreakStmt struct {
 	BaseNode
+	Label *Literal // break label，可选
 }
 
-func NewBreakStmt() *BreakStmt {
+func NewBreakStmt(label *Literal) *BreakStmt {
 	return &BreakStmt{
 		BaseNode: BaseNode{Type: NodeTypeBreakStmt},
+		Label:    label,
 	}
 }
 
@@ -773,17 +1235,22 @@ func (b *BreakStmt) NodeType() NodeType {
 }
 
 func (b *BreakStmt) String() string {
+	if b.Label != nil {
+		return fmt.Sprintf("BreakStmt(%s)", b.Label.Value.Value)
+	}
 	return "BreakStmt"
 }
 
 // ContinueStmt
 type ContinueStmt struct {
 	BaseNode
+	Label *Literal // continue label，可选
 }
 
-func NewContinueStmt() *ContinueStmt {
+func NewContinueStmt(label *Literal) *ContinueStmt {
 	return &ContinueStmt{
 		BaseNode: BaseNode{Type: NodeTypeContinueStmt},
+		Label:    label,
 	}
 }
 
@@ -792,6 +1259,9 @@ func (c *ContinueStmt) NodeType() NodeType {
 }
 
 func (c *ContinueStmt) String() string {
+	if c.Label != nil {
+		return fmt.Sprintf("ContinueStmt(%s)", c.Label.Value.Value)
+	}
 	return "ContinueStmt"
 }
 
@@ -819,7 +1289,8 @@ func (e *ExpressionStmt) String() string {
 // TaskStmt
 type TaskStmt struct {
 	BaseNode
-	Fn FunctionDecl
+	Fn  FunctionDecl
+	Doc *CommentGroup // 文档注释，可能为 nil
 }
 
 func NewTaskStmt(fn FunctionDecl) *TaskStmt {
@@ -0,0 +1,416 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode"
+	"vine-lang/token"
+)
+
+// JSONVersion AST JSON 格式的版本号，格式发生不兼容的变化时递增
+const JSONVersion = 1
+
+// 内置节点类型对应的结构体，用于 JSON 编解码
+// 结构体名即 JSON 中的 type 名，属于格式的一部分，重命名结构体时需要递增 JSONVersion
+var nodeStructs = map[NodeType]reflect.Type{
+	NodeTypeProgramStmt:         reflect.TypeFor[ProgramStmt](),
+	NodeTypeBlockStmt:           reflect.TypeFor[BlockStmt](),
+	NodeTypeUseDecl:             reflect.TypeFor[UseDecl](),
+	NodeTypeExpressionStmt:      reflect.TypeFor[ExpressionStmt](),
+	NodeTypeVariableDecl:        reflect.TypeFor[VariableDecl](),
+	NodeTypeExposeStmt:          reflect.TypeFor[ExposeStmt](),
+	NodeTypeForStmt:             reflect.TypeFor[ForStmt](),
+	NodeTypeIfStmt:              reflect.TypeFor[IfStmt](),
+	NodeTypeFunctionDecl:        reflect.TypeFor[FunctionDecl](),
+	NodeTypeLambdaFunctionDecl:  reflect.TypeFor[LambdaFunctionDecl](),
+	NodeTypeReturnStmt:          reflect.TypeFor[ReturnStmt](),
+	NodeTypeSwitchStmt:          reflect.TypeFor[SwitchStmt](),
+	NodeTypeTaskStmt:            reflect.TypeFor[TaskStmt](),
+	NodeTypeWaitStmt:            reflect.TypeFor[WaitStmt](),
+	NodeTypeCallTaskFn:          reflect.TypeFor[CallTaskFn](),
+	NodeTypeToExpr:              reflect.TypeFor[ToExpr](),
+	NodeTypeAssignmentExpr:      reflect.TypeFor[AssignmentExpr](),
+	NodeTypeCompareExpr:         reflect.TypeFor[CompareExpr](),
+	NodeTypeBinaryExpr:          reflect.TypeFor[BinaryExpr](),
+	NodeTypeProperty:            reflect.TypeFor[Property](),
+	NodeTypeArrayExpr:           reflect.TypeFor[ArrayExpr](),
+	NodeTypeObjectExpr:          reflect.TypeFor[ObjectExpr](),
+	NodeTypeMemberExpr:          reflect.TypeFor[MemberExpr](),
+	NodeTypeArgsExpr:            reflect.TypeFor[ArgsExpr](),
+	NodeTypeCallExpr:            reflect.TypeFor[CallExpr](),
+	NodeTypeUnaryExpr:           reflect.TypeFor[UnaryExpr](),
+	NodeTypeLiteral:             reflect.TypeFor[Literal](),
+	NodeTypeUseSpecifier:        reflect.TypeFor[UseSpecifier](),
+	NodeTypeSwitchCase:          reflect.TypeFor[SwitchCase](),
+	NodeTypeBreakStmt:           reflect.TypeFor[BreakStmt](),
+	NodeTypeContinueStmt:        reflect.TypeFor[ContinueStmt](),
+	NodeTypeTemplateLiteralExpr: reflect.TypeFor[TemplateLiteralExpr](),
+	NodeTypeTemplateElement:     reflect.TypeFor[TemplateElement](),
+	NodeTypeTernaryExpr:         reflect.TypeFor[TernaryExpr](),
+	NodeTypeRangeExpr:           reflect.TypeFor[RangeExpr](),
+	NodeTypeArrayPattern:        reflect.TypeFor[ArrayPattern](),
+	NodeTypeObjectPattern:       reflect.TypeFor[ObjectPattern](),
+	NodeTypeParameter:           reflect.TypeFor[Parameter](),
+	NodeTypeNamedArg:            reflect.TypeFor[NamedArg](),
+	NodeTypeSpreadExpr:          reflect.TypeFor[SpreadExpr](),
+	NodeTypeChainExpr:           reflect.TypeFor[ChainExpr](),
+	NodeTypeMatchExpr:           reflect.TypeFor[MatchExpr](),
+	NodeTypeMatchArm:            reflect.TypeFor[MatchArm](),
+	NodeTypeTypePattern:         reflect.TypeFor[TypePattern](),
+	NodeTypeWhileStmt:           reflect.TypeFor[WhileStmt](),
+	NodeTypeTypeDecl:            reflect.TypeFor[TypeDecl](),
+	NodeTypePinPattern:          reflect.TypeFor[PinPattern](),
+	NodeTypeCommentStmt:         reflect.TypeFor[CommentStmt](),
+}
+
+// String 返回节点类型的名字，即 JSON 中的 type 字段
+func (t NodeType) String() string {
+	if s, ok := nodeStructs[t]; ok {
+		return s.Name()
+	}
+	if name, ok := CustomNodeName(t); ok {
+		return name
+	}
+	return fmt.Sprintf("NodeType(%d)", t)
+}
+
+// lookupNodeType 按名字查找节点类型，扩展节点只能编码，不能解码
+func lookupNodeType(name string) (NodeType, reflect.Type, bool) {
+	for t, s := range nodeStructs {
+		if s.Name() == name {
+			return t, s, true
+		}
+	}
+	return 0, nil, false
+}
+
+var (
+	tokenType        = reflect.TypeFor[token.Token]()
+	commentGroupType = reflect.TypeFor[CommentGroup]()
+	baseNodeType     = reflect.TypeFor[BaseNode]()
+)
+
+// jsonDocument JSON 的最外层，File 为所有节点范围共用的文件名
+type jsonDocument struct {
+	Version int             `json:"version"`
+	File    string          `json:"file"`
+	Root    json.RawMessage `json:"root"`
+}
+
+type jsonSpan struct {
+	Start token.Pos `json:"start"`
+	End   token.Pos `json:"end"`
+}
+
+type jsonToken struct {
+	Type  token.TokenType `json:"type"`
+	Value string          `json:"value"`
+	Start token.Pos       `json:"start"`
+	End   token.Pos       `json:"end"`
+}
+
+type jsonCommentGroup struct {
+	List []jsonToken `json:"list"`
+}
+
+// jsonObject 按字段顺序输出的 JSON 对象，保证编码结果稳定
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value any
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		val, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// EncodeJSON 将节点及其所有子节点编码为带版本号的 JSON，包含位置与注释
+//
+//	{"version": 1, "file": "main.vine", "root": {"type": "ProgramStmt", "span": {...}, "body": [...]}}
+//
+// 每个节点包含 type、span，以及结构体字段按小写驼峰命名的同名字段
+func EncodeJSON(node Node) ([]byte, error) {
+	root, err := encodeNode(node)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	var file string
+	if node != nil {
+		file = node.NodeSpan().File
+	}
+	return json.Marshal(jsonDocument{Version: JSONVersion, File: file, Root: data})
+}
+
+// DecodeJSON 解码 EncodeJSON 的输出，版本不一致时返回错误
+func DecodeJSON(data []byte) (Node, error) {
+	var doc jsonDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported AST JSON version %d (expected %d)", doc.Version, JSONVersion)
+	}
+	return decodeNode(doc.Root, doc.File)
+}
+
+func encodeNode(node Node) (any, error) {
+	v := reflect.ValueOf(node)
+	if node == nil || v.IsNil() {
+		return nil, nil
+	}
+	span := node.NodeSpan()
+	obj := jsonObject{
+		{"type", node.NodeType().String()},
+		{"span", jsonSpan{Start: span.Start, End: span.End}},
+	}
+	v = v.Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Type == baseNodeType {
+			if tk := v.Field(i).Interface().(BaseNode).Token; tk != nil {
+				obj = append(obj, jsonField{"token", encodeToken(*tk)})
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		val, err := encodeValue(v.Field(i))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", v.Type().Name(), field.Name, err)
+		}
+		obj = append(obj, jsonField{jsonKey(field.Name), val})
+	}
+	return obj, nil
+}
+
+func encodeValue(v reflect.Value) (any, error) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if n, ok := v.Interface().(Node); ok {
+			return encodeNode(n)
+		}
+		return encodeValue(v.Elem())
+	case reflect.Struct:
+		switch {
+		case v.Type() == tokenType:
+			return encodeToken(v.Interface().(token.Token)), nil
+		case v.Type() == commentGroupType:
+			group := jsonCommentGroup{List: []jsonToken{}}
+			for _, tk := range v.Interface().(CommentGroup).List {
+				group.List = append(group.List, encodeToken(tk))
+			}
+			return group, nil
+		case reflect.PointerTo(v.Type()).Implements(nodeInterface):
+			// 按值内嵌的节点，零值为占位
+			if v.IsZero() {
+				return nil, nil
+			}
+			ptr := reflect.New(v.Type())
+			ptr.Elem().Set(v)
+			return encodeNode(ptr.Interface().(Node))
+		}
+	case reflect.Slice:
+		list := make([]any, 0, v.Len())
+		for i := range v.Len() {
+			item, err := encodeValue(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	}
+	return nil, fmt.Errorf("unsupported field type %s", v.Type())
+}
+
+func encodeToken(tk token.Token) jsonToken {
+	return jsonToken{Type: tk.Type, Value: tk.Value, Start: tk.Pos(), End: tk.End}
+}
+
+func decodeToken(tk jsonToken) token.Token {
+	return token.Token{
+		Type:   tk.Type,
+		Value:  tk.Value,
+		Line:   tk.Start.Line,
+		Column: tk.Start.Column,
+		Offset: tk.Start.Offset,
+		End:    tk.End,
+	}
+}
+
+func decodeNode(data json.RawMessage, file string) (Node, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var name string
+	if err := json.Unmarshal(fields["type"], &name); err != nil {
+		return nil, fmt.Errorf("node without type: %s", data)
+	}
+	nodeType, structType, ok := lookupNodeType(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", name)
+	}
+
+	ptr := reflect.New(structType)
+	v := ptr.Elem()
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if field.Type == baseNodeType {
+			base := BaseNode{Type: nodeType}
+			var span jsonSpan
+			if raw, ok := fields["span"]; ok {
+				if err := json.Unmarshal(raw, &span); err != nil {
+					return nil, fmt.Errorf("%s.span: %w", name, err)
+				}
+			}
+			base.Span = Span{File: file, Start: span.Start, End: span.End}
+			if raw, ok := fields["token"]; ok && !isNull(raw) {
+				var tk jsonToken
+				if err := json.Unmarshal(raw, &tk); err != nil {
+					return nil, fmt.Errorf("%s.token: %w", name, err)
+				}
+				t := decodeToken(tk)
+				base.Token = &t
+			}
+			v.Field(i).Set(reflect.ValueOf(base))
+			continue
+		}
+		raw, ok := fields[jsonKey(field.Name)]
+		if !field.IsExported() || !ok {
+			continue
+		}
+		if err := decodeValue(raw, v.Field(i), file); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", name, jsonKey(field.Name), err)
+		}
+	}
+	return ptr.Interface().(Node), nil
+}
+
+func decodeValue(data json.RawMessage, v reflect.Value, file string) error {
+	if isNull(data) {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		n, err := decodeNode(data, file)
+		if err != nil {
+			return err
+		}
+		if !reflect.TypeOf(n).AssignableTo(v.Type()) {
+			return fmt.Errorf("%s is not a %s", n.NodeType(), v.Type())
+		}
+		v.Set(reflect.ValueOf(n))
+		return nil
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := decodeValue(data, elem.Elem(), file); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		switch {
+		case v.Type() == tokenType:
+			var tk jsonToken
+			if err := json.Unmarshal(data, &tk); err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(decodeToken(tk)))
+			return nil
+		case v.Type() == commentGroupType:
+			var group jsonCommentGroup
+			if err := json.Unmarshal(data, &group); err != nil {
+				return err
+			}
+			list := make([]token.Token, 0, len(group.List))
+			for _, tk := range group.List {
+				list = append(list, decodeToken(tk))
+			}
+			v.Set(reflect.ValueOf(CommentGroup{List: list}))
+			return nil
+		case reflect.PointerTo(v.Type()).Implements(nodeInterface):
+			n, err := decodeNode(data, file)
+			if err != nil {
+				return err
+			}
+			if reflect.TypeOf(n).Elem() != v.Type() {
+				return fmt.Errorf("%s is not a %s", n.NodeType(), v.Type().Name())
+			}
+			v.Set(reflect.ValueOf(n).Elem())
+			return nil
+		}
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, list.Index(i), file); err != nil {
+				return fmt.Errorf("[%d]: %w", i, err)
+			}
+		}
+		v.Set(list)
+		return nil
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+	return fmt.Errorf("unsupported field type %s", v.Type())
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(bytes.TrimSpace(data)) == "null"
+}
+
+// jsonKey 将字段名转换为小写驼峰形式，如 IsConst -> isConst，ID -> id
+func jsonKey(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		// 连续的大写字母整体转小写，但保留下一个单词的首字母
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
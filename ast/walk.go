@@ -0,0 +1,86 @@
+package ast
+
+import (
+	"reflect"
+	"vine-lang/token"
+)
+
+// Span 节点在源码中的范围，End 指向最后一个字符之后
+type Span struct {
+	File  string
+	Start token.Pos
+	End   token.Pos
+}
+
+func (s Span) IsZero() bool {
+	return s.Start == token.Pos{} && s.End == token.Pos{}
+}
+
+// SpanOf 返回 token 覆盖的范围
+func SpanOf(file string, tk token.Token) Span {
+	return Span{File: file, Start: tk.Pos(), End: tk.End}
+}
+
+var nodeInterface = reflect.TypeFor[Node]()
+
+// nodeString 返回节点的字符串形式，可选节点为空时返回 nil
+func nodeString(node Node) string {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return "nil"
+	}
+	return node.String()
+}
+
+// Children 按字段顺序返回节点的直接子节点
+func Children(node Node) []Node {
+	v := reflect.ValueOf(node)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	var children []Node
+	v = v.Elem()
+	for i := range v.NumField() {
+		if v.Type().Field(i).Anonymous {
+			continue
+		}
+		children = appendNodes(children, v.Field(i))
+	}
+	return children
+}
+
+func appendNodes(list []Node, v reflect.Value) []Node {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return list
+		}
+		return appendNodes(list, v.Elem())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return list
+		}
+		if n, ok := v.Interface().(Node); ok {
+			return append(list, n)
+		}
+	case reflect.Struct:
+		// 按值内嵌的节点，如 ForStmt.Body；零值为占位，不是子节点
+		if !v.IsZero() && v.CanAddr() && v.Addr().Type().Implements(nodeInterface) {
+			return append(list, v.Addr().Interface().(Node))
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			list = appendNodes(list, v.Index(i))
+		}
+	}
+	return list
+}
+
+// Walk 深度优先遍历节点，fn 返回 false 时跳过该节点的子节点
+func Walk(node Node, fn func(Node) bool) {
+	if node == nil || !fn(node) {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(child, fn)
+	}
+}
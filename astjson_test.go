@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vine-lang/ast"
+	"vine-lang/env"
+	"vine-lang/ipt"
+	"vine-lang/lexer"
+	"vine-lang/parser"
+)
+
+// TestASTJSONRoundTrip 测试所有示例的 AST 编码后再解码得到相同的树
+func TestASTJSONRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("examples/**/*.vine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, _ := filepath.Glob("examples/*.vine")
+	files = append(top, files...)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lex := lexer.New(file, string(content))
+		lex.Parse()
+		p := parser.CreateParser(lex)
+		program := p.ParseProgram()
+		if err := p.Err(); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		// vine ast 的文本输出不应因可选节点为空而崩溃
+		_ = program.String()
+
+		data, err := ast.EncodeJSON(program)
+		if err != nil {
+			t.Fatalf("%s: encode: %v", file, err)
+		}
+		decoded, err := ast.DecodeJSON(data)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", file, err)
+		}
+		again, err := ast.EncodeJSON(decoded)
+		if err != nil {
+			t.Fatalf("%s: re-encode: %v", file, err)
+		}
+		if !bytes.Equal(data, again) {
+			t.Errorf("%s: JSON changed after round trip", file)
+		}
+	}
+}
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/ast.json")
+
+// TestASTJSONGolden 固定 JSON 的字段名与节点的 type 名，格式变化时需要同步修改 testdata/ast.json
+// 确认变化后可以用 go test -run ASTJSONGolden -update 重新生成
+func TestASTJSONGolden(t *testing.T) {
+	const file = "testdata/ast.vine"
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lex := lexer.New(file, string(content))
+	lex.Parse()
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ast.EncodeJSON(program)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := json.Indent(&got, data, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	got.WriteByte('\n')
+
+	const golden = "testdata/ast.json"
+	if *updateGolden {
+		if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("AST JSON of %s differs from %s; rerun with -update if the format change is intended", file, golden)
+	}
+}
+
+// TestASTJSONEval 测试解码得到的树可以直接执行
+func TestASTJSONEval(t *testing.T) {
+	code := "let total = 0\nfor x in [1, 2, 3]:\n    total += x * 2\nend\ntotal\n"
+	lex := lexer.New("json.vine", code)
+	lex.Parse()
+	p := parser.CreateParser(lex)
+	data, err := ast.EncodeJSON(p.ParseProgram())
+	if err != nil {
+		t.Fatal(err)
+	}
+	program, err := ast.DecodeJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := env.New(env.Workspace{Root: ".", BasePath: "."})
+	e.FileName = "json.vine"
+	res, err := ipt.New(p, e).Eval(program, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != int64(12) {
+		t.Errorf("total = %v, want 12", res)
+	}
+
+	if _, err := ast.DecodeJSON([]byte(`{"version": 99, "file": "", "root": null}`)); err == nil {
+		t.Error("expected error for unsupported version")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"vine-lang/pprof"
 	"vine-lang/repl"
 	"vine-lang/utils"
+	"vine-lang/verror"
 
 	"github.com/spf13/cobra"
 )
@@ -18,6 +19,9 @@ const version = "v1.0.1"
 var (
 	cpuProfile string
 	memProfile string
+	astJSON    bool
+	fmtCheck   bool
+	fmtDiff    bool
 )
 
 var rootCmd = &cobra.Command{
@@ -83,6 +87,35 @@ var runCmd = &cobra.Command{
 	},
 }
 
+var astCmd = &cobra.Command{
+	Use:   "ast <file>",
+	Short: "print the syntax tree of a vine script file",
+	Long:  `Parse a vine script file and print its syntax tree, use --json for the versioned JSON encoding`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printAST(args[0], astJSON); err != nil {
+			if _, ok := err.(verror.ErrorList); ok {
+				handleError(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [path...]",
+	Short: "format vine script files",
+	Long:  `Rewrite vine script files in the canonical style, directories are formatted recursively. Use --check to list unformatted files and --diff to print the changes without writing them`,
+	Run: func(cmd *cobra.Command, args []string) {
+		unformatted, failed := formatPaths(args, fmtCheck, fmtDiff)
+		if failed || (fmtCheck && unformatted) {
+			os.Exit(1)
+		}
+	},
+}
+
 // 执行文件或项目
 func RunProjectOrFile(cmd *cobra.Command, args []string) {
 	wk, err := GetWorkSpaceWithArgs(args)
@@ -111,7 +144,11 @@ func RunProjectOrFile(cmd *cobra.Command, args []string) {
 	finnal, err := filepath.Abs(targetFileName)
 
 	if err := executeVineFile(finnal, *wk); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if _, ok := err.(verror.ErrorList); ok {
+			handleError(err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -121,6 +158,12 @@ func init() {
 	rootCmd.AddCommand(replCmd)
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(astCmd)
+	rootCmd.AddCommand(fmtCmd)
+
+	astCmd.Flags().BoolVar(&astJSON, "json", false, "print the syntax tree as JSON")
+	fmtCmd.Flags().BoolVar(&fmtCheck, "check", false, "list files whose formatting differs and exit with status 1")
+	fmtCmd.Flags().BoolVar(&fmtDiff, "diff", false, "print the formatting changes as a unified diff instead of rewriting files")
 
 	// 添加pprof标志
 	rootCmd.PersistentFlags().StringVar(&cpuProfile, "cpuprofile", "", "write cpu profile to file")
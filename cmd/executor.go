@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"vine-lang/ast"
 	"vine-lang/env"
 	"vine-lang/ipt"
 	"vine-lang/lexer"
@@ -34,6 +37,9 @@ func executeVineFile(filepath string, wk env.Workspace) error {
 func executeCode(filename string, code string, wk env.Workspace) (any, error) {
 	lex := lexer.New(filename, code)
 	lex.Parse()
+	if err := lex.Err(); err != nil {
+		return nil, err
+	}
 
 	p := parser.CreateParser(lex)
 
@@ -47,6 +53,9 @@ func executeCode(filename string, code string, wk env.Workspace) (any, error) {
 
 func handleError(r any) {
 	switch err := r.(type) {
+	case verror.ErrorList:
+		// 逐行输出所有错误
+		fmt.Fprintln(os.Stderr, err.Error())
 	case verror.VError:
 		fmt.Fprintln(os.Stderr, err.Error())
 	case verror.ParseVError:
@@ -59,3 +68,39 @@ func handleError(r any) {
 		fmt.Fprintln(os.Stderr, r)
 	}
 }
+
+// printAST 解析文件并输出语法树，asJSON 为 true 时输出 JSON
+func printAST(filename string, asJSON bool) error {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("无法读取文件 %s: %v", filename, err)
+	}
+
+	lex := lexer.New(filename, string(content))
+	lex.Parse()
+	if err := lex.Err(); err != nil {
+		return err
+	}
+
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		return err
+	}
+
+	if !asJSON {
+		program.Print()
+		return nil
+	}
+	data, err := ast.EncodeJSON(program)
+	if err != nil {
+		return err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, data, "", "  "); err != nil {
+		return err
+	}
+	out.WriteByte('\n')
+	_, err = out.WriteTo(os.Stdout)
+	return err
+}
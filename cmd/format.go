@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"vine-lang/format"
+)
+
+// formatPaths 格式化文件或目录下的所有 .vine 文件
+// check 与 diff 都为 false 时写回文件；返回是否存在格式不符的文件，以及是否有文件出错
+func formatPaths(paths []string, check, diff bool) (unformatted, failed bool) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// 目录中只处理 .vine 文件，直接指定的文件总是处理
+			if d.IsDir() || (file != path && filepath.Ext(file) != ".vine") {
+				return nil
+			}
+			changed, err := formatFile(file, check, diff)
+			if err != nil {
+				handleError(err)
+				failed = true
+			}
+			unformatted = unformatted || changed
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed = true
+		}
+	}
+	return unformatted, failed
+}
+
+func formatFile(file string, check, diff bool) (bool, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return false, fmt.Errorf("无法读取文件 %s: %v", file, err)
+	}
+	formatted, err := format.Source(file, content)
+	if err != nil {
+		return false, err
+	}
+	if string(formatted) == string(content) {
+		return false, nil
+	}
+	if check {
+		fmt.Println(file)
+	}
+	if diff {
+		fmt.Print(unifiedDiff(file, string(content), string(formatted)))
+	}
+	if check || diff {
+		return true, nil
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return true, err
+	}
+	return true, os.WriteFile(file, formatted, info.Mode().Perm())
+}
+
+// 差异中改动前后保留的上下文行数
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' '、'-' 或 '+'
+	text string
+}
+
+// diffLines 按最长公共子序列逐行比较 a 与 b
+func diffLines(a, b []string) []diffLine {
+	// 相同的开头与结尾不参与计算
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] 为 x[i:] 与 y[j:] 的最长公共子序列长度
+	lcs := make([][]int32, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, diffLine{' ', x[i]})
+			i, j = i+1, j+1
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{'-', x[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', y[j]})
+			j++
+		}
+	}
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// unifiedDiff 生成从 before 到 after 的统一格式差异
+func unifiedDiff(name, before, after string) string {
+	lines := diffLines(splitLines(before), splitLines(after))
+	// 每一行之前已经过的原文件与新文件行数
+	aPos := make([]int, len(lines)+1)
+	bPos := make([]int, len(lines)+1)
+	for k, line := range lines {
+		aPos[k+1], bPos[k+1] = aPos[k], bPos[k]
+		if line.op != '+' {
+			aPos[k+1]++
+		}
+		if line.op != '-' {
+			bPos[k+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", name, name)
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		// 上下文相交的改动合并为一段
+		start, end := max(i-diffContext, 0), i
+		for j := i; j < len(lines) && j-end <= 2*diffContext; j++ {
+			if lines[j].op != ' ' {
+				end = j
+			}
+		}
+		stop := min(end+diffContext+1, len(lines))
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aPos[start], aPos[stop]), hunkRange(bPos[start], bPos[stop]))
+		for _, line := range lines[start:stop] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+func hunkRange(from, to int) string {
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"vine-lang/ast"
+	"vine-lang/lexer"
+	"vine-lang/parser"
+)
+
+// parseProgram 解析代码并返回语法树，出现解析错误时测试失败
+func parseProgram(t *testing.T, code string) *ast.ProgramStmt {
+	t.Helper()
+	lex := lexer.New("comment.vine", code)
+	lex.Parse()
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		t.Fatalf("%q: %v", code, err)
+	}
+	return program
+}
+
+// TestDocComments 测试 ## 文档注释附加到紧随其后的声明上
+func TestDocComments(t *testing.T) {
+	code := "## Adds two numbers.\n##  Indented line.\nfn add(a, b): a + b end\n\n" +
+		"## Default port\ncst PORT = 8080\n\n" +
+		"## Runs in the background\ntask fn job(): 1 end\n\n" +
+		"## Exported state\nexpose STATE = {}\n\n" +
+		"## not attached\n\nlet plain = 1\n"
+	program := parseProgram(t, code)
+
+	var docs []*ast.CommentGroup
+	for _, stmt := range program.Body {
+		switch n := stmt.(type) {
+		case *ast.FunctionDecl:
+			docs = append(docs, n.Doc)
+		case *ast.VariableDecl:
+			docs = append(docs, n.Doc)
+		case *ast.TaskStmt:
+			docs = append(docs, n.Doc)
+		case *ast.ExposeStmt:
+			docs = append(docs, n.Doc)
+		}
+	}
+	want := []string{"Adds two numbers.\n Indented line.", "Default port", "Runs in the background", "Exported state", ""}
+	if len(docs) != len(want) {
+		t.Fatalf("got %d declarations, want %d", len(docs), len(want))
+	}
+	for index, doc := range docs {
+		if got := doc.Text(); got != want[index] {
+			t.Errorf("declaration %d: doc = %q, want %q", index, got, want[index])
+		}
+	}
+	if docs[4] != nil {
+		t.Errorf("doc separated by a blank line should not attach, got %q", docs[4].Text())
+	}
+}
+
+// TestBlockCommentsInExpressions 测试表达式中任意位置的块注释都被跳过
+func TestBlockCommentsInExpressions(t *testing.T) {
+	checkValues(t, "comment.vine", []valueTest{
+		{"let a = 1 #[ c ]# + 2\na", int64(3)},
+		{"let a = 1 + #[ c ]# 2 #[ d ]# * 3\na", int64(7)},
+		{"[1, #[ x ]# 2 #[ y ]#]", []any{int64(1), int64(2)}},
+		{"let o = {a #[ k ]#: #[ v ]# 1}\no.a", int64(1)},
+		{"fn f(a, b): a - b end\nf(#[ a ]# 5, 2 #[ b ]#)", int64(3)},
+		{"let a = [\n    1,\n    #[ x ]#\n    2,\n]\na", []any{int64(1), int64(2)}},
+		{"#[ lead ]# let a = 2\n#[ own line ]#\na #[ c ]# ** 2", int64(4)},
+	})
+}
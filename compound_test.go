@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+// TestCompoundAssignment 测试复合赋值的结果与表达式的值
+func TestCompoundAssignment(t *testing.T) {
+	checkValues(t, "compound.vine", []valueTest{
+		{"let n = 10\nn += 5\nn -= 3\nn *= 2\nn", int64(24)},
+		{"let n = 24\nn /= 4\nn", int64(6)},
+		{"let n = 7\nn /= 2\nn", 3.5},
+		{"let m = 17\nm %= 5\nm **= 3\nm //= 3\nm", int64(2)},
+		{"let m = -7\nm %= 3\nm", int64(2)},
+		{"let s = \"foo\"\ns += \"bar\"\ns", "foobar"},
+		{"let o = {count: 1, items: [1, 2, 3]}\no.count += 10\no[\"count\"] *= 2\no.items[1] += 40\n[o.count, o.items]", []any{int64(22), []any{int64(1), int64(42), int64(3)}}},
+		{"let x = 1\nx += 1", int64(2)},
+		{"let total = 0\nfor i in 1..10:\n    total += i\nend\ntotal", int64(55)},
+	})
+}
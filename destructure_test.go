@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDestructuring 测试 let、函数参数与 for 循环中的解构绑定
+func TestDestructuring(t *testing.T) {
+	checkValues(t, "destructure.vine", []valueTest{
+		{"let [a, b, [c, d]] = [1, 2, [3, 4]]\n[a, b, c, d]", []any{int64(1), int64(2), int64(3), int64(4)}},
+		{"let {name, age as years, pos: {x, y}} = {name: \"vine\", age: 3, pos: {x: 10, y: 20}}\n[name, years, x, y]", []any{"vine", int64(3), int64(10), int64(20)}},
+		{"fn area({width, height}): width * height end\narea({width: 3, height: 4})", int64(12)},
+		{"let swap = fn([p, q]): [q, p] end\nswap([1, 2])", []any{int64(2), int64(1)}},
+		{"let out = []\nfor i, v in [\"a\", \"b\"]:\n    out = [...out, i, v]\nend\nout", []any{int64(0), "a", int64(1), "b"}},
+		{"let out = []\nfor [k, v] in [[\"x\", 1], [\"y\", 2]]:\n    out = [...out, k, v]\nend\nout", []any{"x", int64(1), "y", int64(2)}},
+		{"let out = []\nfor k, v in {one: 1, two: 2}:\n    out = [...out, k, v]\nend\nout", []any{"one", int64(1), "two", int64(2)}},
+	})
+}
+
+// TestDestructuringErrors 测试解构的结构不匹配时报告错误
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"let [a, b] = [1]\n", "not enough values to destructure: expected 2, got 1"},
+		{"let {missing} = {}\n", "missing"},
+		{"let [a] = {a: 1}\n", "cannot destructure object as array"},
+		{"fn f([x]): x end\nf(1)\n", "cannot destructure int as array"},
+	}
+	for _, tt := range tests {
+		err := runForError("destructure.vine", tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
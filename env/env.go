@@ -42,6 +42,9 @@ type Environment struct {
 	WorkSpace  Workspace
 	Exports    *store.StoreObject
 	isPassing  bool // 是否正在定义临时参数，将不查找父级
+	// Stringer 返回对象自定义的字符串形式，由创建该环境的解释器设置
+	// env 为需要转换的位置所在的环境
+	Stringer func(obj *store.StoreObject, env *Environment) (string, bool)
 }
 
 func New(workspace Workspace) *Environment {
@@ -126,6 +129,24 @@ func (e *Environment) Set(name Token, val any) {
 	}
 }
 
+// Assign 为变量赋值，沿作用域链查找变量所在的环境
+// 常量不可重新赋值；变量不存在时在当前环境中定义
+func (e *Environment) Assign(name Token, val any) {
+	for cur := e; cur != nil; cur = cur.parent {
+		if _, exists := cur.store[name.Value]; exists {
+			if _, isConst := cur.consts[name.Value]; isConst {
+				panic(verror.InterpreterVError{
+					Position: name.ToPosition(e.FileName),
+					Message:  fmt.Sprintf("constant %s cannot be reassigned", LibsUtils.TrasformPrintString(name.Value)),
+				})
+			}
+			cur.store[name.Value] = val
+			return
+		}
+	}
+	e.store[name.Value] = val
+}
+
 // SetFast 快速设置变量值，用于已知变量存在且不是常量的情况
 // 仅在当前环境查找，不遍历父环境
 // 使用字符串作为map key以减少哈希计算开销
@@ -179,6 +200,16 @@ func (e *Environment) Delete(name Token) {
 	delete(e.nameMap, name.Value)
 }
 
+// ObjectString 沿作用域链找到最近的 Stringer，并在当前环境中转换对象
+func (e *Environment) ObjectString(obj *store.StoreObject) (string, bool) {
+	for cur := e; cur != nil; cur = cur.parent {
+		if cur.Stringer != nil {
+			return cur.Stringer(obj, e)
+		}
+	}
+	return "", false
+}
+
 func (e *Environment) Print() {
 	for k, v := range e.store {
 		println(k, LibsUtils.TrasformPrintString(v))
@@ -17,6 +17,7 @@ func NewPooled(fileName string) *Environment {
 	e.FileName = fileName
 	e.parent = nil
 	e.Exports = nil
+	e.Stringer = nil
 	for k := range e.consts {
 		delete(e.consts, k)
 	}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+// TestEquality 测试 ==、!= 与 in 的值比较，对象与数组按内容比较
+func TestEquality(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"{a: 1} == {a: 1}", true},
+		{"{a: 1, b: [1, {c: 2}]} == {b: [1, {c: 2}], a: 1}", true},
+		{"{a: 1} == {a: 2}", false},
+		{"{a: 1} == {a: 1, b: 2}", false},
+		{"{a: 1} != {a: 1}", false},
+		{"fn mk(): {a: 1} end\nmk() == mk()", true},
+		{"fn mk(n): {a: n} end\nmk(1) == mk(2)", false},
+		{"let o = {a: 1}\no == o", true},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, 2.0] == [1.0, 2]", true},
+		{"{a: 1} == [1]", false},
+		{"{} == nil", false},
+		{"{a: 1} in [{a: 2}, {a: 1}]", true},
+		{"[1] in [[2], [1]]", true},
+		{"type P:\n    let x = 1\nend\nP() == P()", true},
+		{"type P:\n    let x = 1\nend\nP() == {x: 1}", false},
+		{"fn f(): 1 end\nlet g = f\nf == g", true},
+		{"fn f(): 1 end\nfn g(): 1 end\nf == g", false},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "equal.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
@@ -161,6 +161,9 @@ func benchmarkVineFile(b *testing.B, filepath string) {
 func executeCode(filename string, code string, wk env.Workspace) (any, error) {
 	lex := lexer.New(filename, code)
 	lex.Parse()
+	if err := lex.Err(); err != nil {
+		return nil, err
+	}
 
 	p := parser.CreateParser(lex)
 
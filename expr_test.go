@@ -0,0 +1,24 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// TestArithmetic 测试取模与整除向下取整、幂运算的结合性与优先级
+func TestArithmetic(t *testing.T) {
+	checkValues(t, "expr.vine", []valueTest{
+		{"[7 % 3, -7 % 3, 7 % -3, -7 % -3]", []any{int64(1), int64(2), int64(-2), int64(-1)}},
+		{"[7 // 2, -7 // 2, 7 // -2, -7 // -2]", []any{int64(3), int64(-4), int64(-4), int64(3)}},
+		{"[7.5 // 2, -7.5 // 2, 5.5 % 2, -5.5 % 2]", []any{3.0, -4.0, 1.5, 0.5}},
+		{"[7 / 2, 6 / 3, 12 / 2 / 3]", []any{3.5, int64(2), int64(2)}},
+		{"[2 ** 10, 2 ** 3 ** 2, (2 ** 3) ** 2]", []any{int64(1024), int64(512), int64(64)}},
+		{"[-2 ** 2, (-2) ** 2, 2 ** -1, 2 * 3 ** 2]", []any{int64(-4), int64(4), 0.5, int64(18)}},
+		{"[2 ** 62, -2 ** 63, (-2) ** 63, 3 ** 39]", []any{int64(1) << 62, float64(-(1 << 63)), int64(math.MinInt64), int64(4052555153018976267)}},
+		{"[2 ** 63, 2 ** 64, (-2) ** 65, 10 ** 20]", []any{math.Pow(2, 63), math.Pow(2, 64), -math.Pow(2, 65), 1e20}},
+		{"[1 + 2 * 3, 10 - 4 - 3, 2 * 3 % 4, 2 + 3 // 2]", []any{int64(7), int64(3), int64(2), int64(3)}},
+		{"[6 & 3, 6 | 3, 6 ^ 3, ~5, 1 << 4, -16 >> 2]", []any{int64(2), int64(7), int64(5), int64(-6), int64(16), int64(-4)}},
+		{"[1 | 2 ^ 3 & 4, 1 + 1 << 2, 1 < 2 == true]", []any{int64(3), int64(8), true}},
+		{"[0.1 + 0.2 == 0.3, 1 == 1.0, 2 > 1.5]", []any{false, true, true}},
+	})
+}
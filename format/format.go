@@ -0,0 +1,834 @@
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+	"vine-lang/ast"
+	"vine-lang/lexer"
+	"vine-lang/parser"
+	"vine-lang/token"
+)
+
+// Source 将源码格式化为统一的风格，保留注释以及语句之间的空行分组
+// 格式化结果必须解析为与原代码相同的语法树，否则返回错误
+func Source(filename string, src []byte) ([]byte, error) {
+	program, lex, err := parse(filename, string(src))
+	if err != nil {
+		return nil, err
+	}
+	p := newPrinter(string(src), lex.Tokens())
+	p.stmts(program.Body)
+	p.flush(math.MaxInt)
+	if p.err != nil {
+		return nil, p.err
+	}
+	out := p.bytes()
+
+	formatted, _, err := parse(filename, string(out))
+	if err != nil {
+		return nil, fmt.Errorf("format %s: invalid output: %w", filename, err)
+	}
+	same, err := sameTree(program, formatted)
+	if err != nil {
+		return nil, err
+	}
+	if !same {
+		return nil, fmt.Errorf("format %s: output changes the syntax tree", filename)
+	}
+	return out, nil
+}
+
+func parse(filename, src string) (*ast.ProgramStmt, *lexer.Lexer, error) {
+	lex := lexer.New(filename, src)
+	lex.Parse()
+	if err := lex.Err(); err != nil {
+		return nil, nil, err
+	}
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		return nil, nil, err
+	}
+	return program, lex, nil
+}
+
+// sameTree 比较两棵语法树，忽略位置、关键字大小写以及注释末尾的空白
+func sameTree(a, b ast.Node) (bool, error) {
+	var trees [2]any
+	for i, node := range []ast.Node{a, b} {
+		data, err := ast.EncodeJSON(node)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(data, &trees[i]); err != nil {
+			return false, err
+		}
+		trees[i] = normalize(trees[i].(map[string]any)["root"])
+	}
+	return reflect.DeepEqual(trees[0], trees[1]), nil
+}
+
+func normalize(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		delete(v, "span")
+		for key, val := range v {
+			// token 的起止位置
+			if pos, ok := val.(map[string]any); ok && pos["offset"] != nil {
+				delete(v, key)
+				continue
+			}
+			v[key] = normalize(val)
+		}
+		if typ, ok := v["type"].(string); ok {
+			if value, ok := v["value"].(string); ok {
+				switch {
+				case token.LookupIdent(value) == token.TokenType(typ) && typ != string(token.IDENT):
+					v["value"] = strings.ToLower(value)
+				case typ == string(token.COMMENT) || typ == string(token.DOC_COMMENT):
+					v["value"] = strings.TrimRight(value, " \t\r")
+				}
+			}
+		}
+	case []any:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+	}
+	return v
+}
+
+const indentUnit = "    "
+
+type printer struct {
+	src      string
+	tokens   []token.Token // 除空白、换行与注释外的 token
+	starts   map[int]int   // 起始偏移 -> tokens 下标
+	ends     map[int]int   // 结束偏移 -> tokens 下标
+	comments []token.Token // 全部注释，按位置排列
+	breaks   map[int]bool  // 注释之后源码是否换行，按注释的偏移索引
+	next     int           // 下一条尚未输出的注释
+
+	buf    []byte
+	indent int
+	bol    bool // 位于行首，缩进尚未输出
+	line   int  // 最近输出的内容在源码中的行号
+	err    error
+}
+
+func newPrinter(src string, tokens []token.Token) *printer {
+	p := &printer{
+		src:    src,
+		starts: map[int]int{},
+		ends:   map[int]int{},
+		breaks: map[int]bool{},
+		bol:    true,
+	}
+	for i, tk := range tokens {
+		switch {
+		case tk.Type == token.WHITESPACE || tk.Type == token.NEWLINE:
+		case tk.IsComment():
+			p.comments = append(p.comments, tk)
+			p.breaks[tk.Offset] = true
+			for _, next := range tokens[i+1:] {
+				if next.Type != token.WHITESPACE {
+					p.breaks[tk.Offset] = next.Type == token.NEWLINE
+					break
+				}
+			}
+		default:
+			p.starts[tk.Offset] = len(p.tokens)
+			p.ends[tk.End.Offset] = len(p.tokens)
+			p.tokens = append(p.tokens, tk)
+		}
+	}
+	return p
+}
+
+func (p *printer) bytes() []byte {
+	out := strings.TrimRight(string(p.buf), " \n")
+	if out == "" {
+		return nil
+	}
+	return []byte(out + "\n")
+}
+
+func (p *printer) write(s string) {
+	if s == "" {
+		return
+	}
+	if p.bol {
+		p.buf = append(p.buf, strings.Repeat(indentUnit, p.indent)...)
+		p.bol = false
+	}
+	p.buf = append(p.buf, s...)
+}
+
+func (p *printer) trimSpace() {
+	for len(p.buf) > 0 && p.buf[len(p.buf)-1] == ' ' {
+		p.buf = p.buf[:len(p.buf)-1]
+	}
+}
+
+func (p *printer) newline() {
+	p.trimSpace()
+	p.buf = append(p.buf, '\n')
+	p.bol = true
+}
+
+// lineEnd 结束当前行，已位于行首时不输出
+func (p *printer) lineEnd() {
+	if !p.bol {
+		p.newline()
+	}
+}
+
+// mark 记录已输出到源码中的 line 行
+func (p *printer) mark(line int) {
+	p.line = max(p.line, line)
+}
+
+func (p *printer) fail(node ast.Node) {
+	if p.err == nil {
+		p.err = fmt.Errorf("format: unsupported syntax %s at line %d", node.NodeType(), node.NodeSpan().Start.Line)
+	}
+}
+
+// comment 输出一条注释，与上一段内容位于源码同一行时放在行尾
+func (p *printer) comment(c token.Token) {
+	if !p.bol {
+		if c.Line == p.line {
+			p.trimSpace()
+			p.write(" ")
+		} else {
+			p.newline()
+		}
+	}
+	p.write(strings.TrimRight(p.src[c.Offset:c.End.Offset], " \t\r"))
+	p.mark(c.End.Line)
+	if p.breaks[c.Offset] {
+		p.newline()
+	} else {
+		p.write(" ")
+	}
+}
+
+// flush 输出位于 offset 之前、尚未输出的注释
+func (p *printer) flush(offset int) {
+	for p.next < len(p.comments) && p.comments[p.next].Offset < offset {
+		p.next++
+		p.comment(p.comments[p.next-1])
+	}
+}
+
+// separate 开始新的一行，源码中与上一段内容之间有空行时保留一个空行
+func (p *printer) separate(first bool, start token.Pos) {
+	// 先输出上一段内容的行尾注释
+	for p.next < len(p.comments) && p.comments[p.next].Offset <= start.Offset && !p.bol && p.comments[p.next].Line == p.line {
+		p.next++
+		p.comment(p.comments[p.next-1])
+	}
+	p.lineEnd()
+	line := start.Line
+	if p.next < len(p.comments) && p.comments[p.next].Offset < start.Offset {
+		line = min(line, p.comments[p.next].Line)
+	}
+	if !first && line > p.line+1 && len(p.buf) > 0 && string(p.buf[len(p.buf)-2:]) != "\n\n" {
+		p.buf = append(p.buf, '\n')
+	}
+}
+
+func singleLine(node ast.Node) bool {
+	span := node.NodeSpan()
+	return span.Start.Line == span.End.Line
+}
+
+/* 语句 */
+
+func (p *printer) stmts(list []ast.Stmt) {
+	for i, stmt := range list {
+		span := stmt.NodeSpan()
+		p.separate(i == 0, span.Start)
+		p.flush(span.Start.Offset)
+		p.node(stmt)
+		p.mark(span.End.Line)
+	}
+}
+
+// inlineStmts 将语句写在同一行，以 '; ' 分隔
+func (p *printer) inlineStmts(list []ast.Stmt) {
+	for i, stmt := range list {
+		if _, ok := stmt.(*ast.CommentStmt); ok {
+			p.node(stmt)
+			continue
+		}
+		if i > 0 {
+			p.write(";")
+		}
+		p.write(" ")
+		p.flush(stmt.NodeSpan().Start.Offset)
+		p.node(stmt)
+	}
+}
+
+// body 输出 ':' 之后的语句，inline 时写在同一行，否则缩进一级逐行输出
+func (p *printer) body(b *ast.BlockStmt, inline bool) {
+	p.write(":")
+	p.mark(b.Span.Start.Line)
+	if inline {
+		p.inlineStmts(b.Body)
+		return
+	}
+	p.indent++
+	p.stmts(b.Body)
+	p.flush(b.Span.End.Offset)
+	p.indent--
+}
+
+// closeEnd 输出结束块的 end
+func (p *printer) closeEnd(inline bool) {
+	if inline {
+		p.write(" end")
+		return
+	}
+	p.lineEnd()
+	p.write("end")
+}
+
+// block 输出 ': ... end' 形式的语句块，源码中写在一行的块保持一行
+func (p *printer) block(b *ast.BlockStmt) {
+	inline := singleLine(b)
+	p.body(b, inline)
+	p.closeEnd(inline)
+}
+
+func (p *printer) label(label *ast.Literal) {
+	if label != nil {
+		p.node(label)
+		p.write(": ")
+	}
+}
+
+func (p *printer) ifStmt(n *ast.IfStmt, inline bool) {
+	p.write("if ")
+	p.node(n.Test)
+	p.body(n.Consequent, inline)
+	switch alt := n.Alternate.(type) {
+	case *ast.IfStmt:
+		p.elseKeyword(inline, alt.Span.Start)
+		p.write("else ")
+		p.ifStmt(alt, inline)
+		return
+	case *ast.BlockStmt:
+		p.elseKeyword(inline, alt.Span.Start)
+		p.write("else")
+		p.body(alt, inline)
+	}
+	p.closeEnd(inline)
+}
+
+func (p *printer) elseKeyword(inline bool, start token.Pos) {
+	if inline {
+		p.write(" ")
+		return
+	}
+	p.lineEnd()
+	p.flush(start.Offset)
+}
+
+func (p *printer) forStmt(n *ast.ForStmt) {
+	p.label(n.Label)
+	p.write("for ")
+	if n.Range != nil {
+		if n.Key != nil {
+			p.node(n.Key)
+			p.write(", ")
+		}
+		p.node(n.Init)
+		p.write(" in ")
+		p.node(n.Range)
+	} else {
+		p.node(n.Init)
+		p.write("; ")
+		p.node(n.Value)
+		p.write("; ")
+		p.node(n.Update)
+	}
+	p.block(&n.Body)
+}
+
+func (p *printer) useDecl(n *ast.UseDecl) {
+	p.write("use ")
+	p.node(n.Source)
+	switch n.Mode {
+	case token.AS:
+		p.write(" as ")
+		p.node(n.Specifiers[0])
+	case token.PICK:
+		p.write(" pick ")
+		if len(n.Specifiers) > 1 {
+			p.write("(")
+		}
+		for i, spec := range n.Specifiers {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.node(spec)
+		}
+		if len(n.Specifiers) > 1 {
+			p.write(")")
+		}
+	}
+}
+
+// cases 输出 switch 的分支或 match 的分支，多行时每个分支缩进一级
+func (p *printer) cases(n ast.Node, arms []ast.Node) {
+	inline := singleLine(n)
+	for i, arm := range arms {
+		start := arm.NodeSpan().Start
+		if inline {
+			p.write(" ")
+			p.flush(start.Offset)
+		} else {
+			p.indent++
+			p.separate(i == 0, start)
+			p.flush(start.Offset)
+		}
+		var body *ast.BlockStmt
+		switch arm := arm.(type) {
+		case *ast.SwitchCase:
+			body = arm.Body
+			if arm.IsDefault {
+				p.write("default")
+			} else {
+				p.write("case ")
+				p.exprs(arm.Conds)
+			}
+		case *ast.MatchArm:
+			body = arm.Body
+			p.write("case ")
+			p.exprs(arm.Patterns)
+			if arm.Guard != nil {
+				p.write(" if ")
+				p.node(arm.Guard)
+			}
+		}
+		p.body(body, inline || singleLine(body))
+		if !inline {
+			p.indent--
+		}
+		p.mark(arm.NodeSpan().End.Line)
+	}
+	p.closeEnd(inline)
+}
+
+func nodes[T ast.Node](list []T) []ast.Node {
+	result := make([]ast.Node, len(list))
+	for i, node := range list {
+		result[i] = node
+	}
+	return result
+}
+
+func (p *printer) exprs(list []ast.Expr) {
+	for i, expr := range list {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.node(expr)
+	}
+}
+
+// callTask 输出 call() to (res): ... catch (e): ... end，多行时 to 与 catch 缩进一级
+func (p *printer) callTask(n *ast.CallTaskFn) {
+	inline := singleLine(n)
+	p.node(&n.Target)
+	clause := func(keyword string, args *ast.ArgsExpr, body *ast.BlockStmt, start token.Pos) {
+		if inline {
+			p.write(" ")
+		} else {
+			p.indent++
+			p.lineEnd()
+		}
+		p.flush(start.Offset)
+		p.write(keyword)
+		if len(args.Arguments) > 0 || keyword == "catch" {
+			p.write(" ")
+			p.list("(", ")", args.Span, nodes(args.Arguments), p.node)
+		}
+		p.body(body, inline)
+		if !inline {
+			p.indent--
+		}
+	}
+	for to := &n.To; to != nil; to = to.Next {
+		clause("to", &to.Args, &to.Body, to.Span.Start)
+	}
+	if n.Catch != nil {
+		clause("catch", &n.Catch.Args, &n.Catch.Body, n.Catch.Span.Start)
+	}
+	p.closeEnd(inline)
+}
+
+/* 表达式 */
+
+// list 输出括号包围的列表，源码中左括号之后换行时每项独占一行并带有末尾逗号
+func (p *printer) list(open, close string, span ast.Span, items []ast.Node, item func(ast.Node)) {
+	p.write(open)
+	multi := len(items) > 0 && p.breakAfter(span.Start.Offset)
+	if multi {
+		p.indent++
+	}
+	hang := false
+	for i, node := range items {
+		if multi {
+			p.separate(i == 0, node.NodeSpan().Start)
+		} else if i > 0 {
+			p.write(", ")
+			// 行尾注释使后面的元素换行时，续行比列表所在的行多缩进一级
+			p.flush(node.NodeSpan().Start.Offset)
+			if p.bol && !hang {
+				hang = true
+				p.indent++
+			}
+		}
+		item(node)
+		if multi {
+			p.write(",")
+		}
+	}
+	p.flush(span.End.Offset - 1)
+	if hang {
+		p.indent--
+	}
+	if multi {
+		p.indent--
+		p.lineEnd()
+	}
+	p.write(close)
+	p.mark(span.End.Line)
+}
+
+// breakAfter 判断源码中位于 offset 的 token 之后是否紧跟换行或注释
+func (p *printer) breakAfter(offset int) bool {
+	i, ok := p.starts[offset]
+	if !ok || i+1 >= len(p.tokens) {
+		return false
+	}
+	return p.tokens[i+1].Line > p.tokens[i].End.Line ||
+		p.next < len(p.comments) && p.comments[p.next].Offset < p.tokens[i+1].Offset
+}
+
+// parenthesized 判断表达式在源码中是否被括号包围
+func (p *printer) parenthesized(node ast.Node) bool {
+	span := node.NodeSpan()
+	i, ok := p.starts[span.Start.Offset]
+	j, ok2 := p.ends[span.End.Offset]
+	if !ok || !ok2 || i == 0 || j+1 >= len(p.tokens) {
+		return false
+	}
+	return p.tokens[i-1].Type == token.LPAREN && p.tokens[j+1].Type == token.RPAREN
+}
+
+// operand 输出运算符的操作数，保留源码中的括号
+func (p *printer) operand(node ast.Node) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+	if !p.parenthesized(node) {
+		p.node(node)
+		return
+	}
+	p.write("(")
+	p.node(node)
+	p.flush(node.NodeSpan().End.Offset)
+	p.write(")")
+}
+
+// operator 输出运算符，关键字运算符统一为小写
+func operator(tk token.Token) string {
+	if tk.Value != "" && unicode.IsLetter(rune(tk.Value[0])) {
+		return strings.ToLower(tk.Value)
+	}
+	return tk.Value
+}
+
+func (p *printer) literal(n *ast.Literal) {
+	tk := n.Value
+	switch {
+	case tk.Type == token.TRUE || tk.Type == token.FALSE || tk.Type == token.NIL:
+		p.write(strings.ToLower(string(tk.Type)))
+	case tk.End.Offset > tk.Offset:
+		p.write(p.src[tk.Offset:tk.End.Offset])
+	default:
+		p.write(tk.Value)
+	}
+	p.mark(tk.End.Line)
+}
+
+// patternProperty 输出解构或匹配模式中的属性：a、a as b 或 a: pattern
+func (p *printer) patternProperty(prop *ast.Property) {
+	p.node(prop.Key)
+	if lit, ok := prop.Value.(*ast.Literal); ok {
+		if lit == prop.Key {
+			return
+		}
+		if lit.Value.Type == token.IDENT {
+			p.write(" as ")
+			p.node(lit)
+			return
+		}
+	}
+	p.write(": ")
+	p.node(prop.Value)
+}
+
+func (p *printer) node(node ast.Node) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+	span := node.NodeSpan()
+	p.flush(span.Start.Offset)
+
+	switch n := node.(type) {
+	case *ast.CommentStmt:
+		p.flush(span.End.Offset)
+	case *ast.ExpressionStmt:
+		p.operand(n.Expression)
+	case *ast.VariableDecl:
+		if n.IsConst {
+			p.write("cst ")
+		} else {
+			p.write("let ")
+		}
+		if n.Pattern != nil {
+			p.node(n.Pattern)
+		} else {
+			p.node(&n.Name)
+		}
+		p.write(" = ")
+		p.node(n.Value)
+	case *ast.ExposeStmt:
+		p.write("expose ")
+		if n.Decl != nil {
+			p.node(n.Decl)
+			break
+		}
+		p.node(n.Name)
+		if n.Value != nil {
+			p.write(" = ")
+			p.node(n.Value)
+		}
+	case *ast.FunctionDecl:
+		p.write("fn ")
+		p.node(n.ID)
+		p.node(n.Arguments)
+		p.block(n.Body)
+	case *ast.TaskStmt:
+		p.write("task ")
+		p.node(&n.Fn)
+	case *ast.ReturnStmt:
+		p.write("return")
+		if n.Value != nil {
+			p.write(" ")
+			p.node(n.Value)
+		}
+	case *ast.WaitStmt:
+		p.write("wait ")
+		p.node(n.Async)
+	case *ast.IfStmt:
+		p.ifStmt(n, singleLine(n))
+	case *ast.ForStmt:
+		p.forStmt(n)
+	case *ast.WhileStmt:
+		p.label(n.Label)
+		if n.Test == nil {
+			p.write("loop")
+		} else {
+			p.write("while ")
+			p.node(n.Test)
+		}
+		p.block(&n.Body)
+	case *ast.TypeDecl:
+		p.write("type ")
+		p.node(n.ID)
+		if n.Parent != nil {
+			p.write("(")
+			p.node(n.Parent)
+			p.write(")")
+		}
+		p.block(n.Body)
+	case *ast.SwitchStmt:
+		p.write("switch ")
+		p.node(n.Test)
+		p.write(":")
+		p.cases(n, nodes(n.Cases))
+	case *ast.BreakStmt:
+		p.write("break")
+		if n.Label != nil {
+			p.write(" ")
+			p.node(n.Label)
+		}
+	case *ast.ContinueStmt:
+		p.write("continue")
+		if n.Label != nil {
+			p.write(" ")
+			p.node(n.Label)
+		}
+	case *ast.UseDecl:
+		p.useDecl(n)
+	case *ast.UseSpecifier:
+		p.node(n.Remote)
+		if n.Local != nil {
+			p.write(" as ")
+			p.node(n.Local)
+		}
+
+	case *ast.Literal:
+		p.literal(n)
+	case *ast.TemplateLiteralExpr:
+		for _, quote := range n.Quotes {
+			if el, ok := quote.(*ast.TemplateElement); ok {
+				p.literal(el.Value)
+			} else {
+				p.node(quote)
+			}
+		}
+	case *ast.ArrayExpr:
+		items := make([]ast.Node, len(n.Items))
+		for i, item := range n.Items {
+			items[i] = item.Value
+		}
+		p.list("[", "]", span, items, p.node)
+	case *ast.ObjectExpr:
+		p.list("{", "}", span, nodes(n.Properties), func(node ast.Node) {
+			prop := node.(*ast.Property)
+			// 展开以及没有写出键的属性只输出值
+			if prop.Key != nil && prop.Key.Value.End.Offset > prop.Key.Value.Offset {
+				p.node(prop.Key)
+				p.write(": ")
+			}
+			p.node(prop.Value)
+		})
+	case *ast.ArrayPattern:
+		p.list("[", "]", span, nodes(n.Elements), p.node)
+	case *ast.ObjectPattern:
+		p.list("{", "}", span, nodes(n.Properties), func(node ast.Node) { p.patternProperty(node.(*ast.Property)) })
+	case *ast.TypePattern:
+		p.write("typeof ")
+		p.node(n.Name)
+	case *ast.PinPattern:
+		p.write("^")
+		p.node(n.Name)
+	case *ast.ArgsExpr:
+		p.list("(", ")", span, nodes(n.Arguments), p.node)
+	case *ast.Parameter:
+		if n.Rest {
+			p.write("...")
+		}
+		p.node(n.Name)
+		if n.Default != nil {
+			p.write(" = ")
+			p.operand(n.Default)
+		}
+	case *ast.NamedArg:
+		p.node(n.Name)
+		p.write(": ")
+		p.node(n.Value)
+	case *ast.SpreadExpr:
+		p.write("...")
+		p.node(n.Value)
+	case *ast.UnaryExpr:
+		if n.IsSuffix {
+			p.operand(n.Value)
+			p.write(operator(n.Operator))
+			break
+		}
+		p.write(operator(n.Operator))
+		if n.Operator.Type == token.NOT || n.Operator.Type == token.TYPEOF {
+			p.write(" ")
+		} else if inner, ok := n.Value.(*ast.UnaryExpr); ok && n.Operator.Type == token.MINUS && !inner.IsSuffix &&
+			(inner.Operator.Type == token.MINUS || inner.Operator.Type == token.DEC) && !p.parenthesized(inner) {
+			// 避免 - -x 被写成 --x
+			p.write(" ")
+		}
+		p.operand(n.Value)
+	case *ast.BinaryExpr:
+		p.operand(n.Left)
+		p.write(" " + operator(n.Operator) + " ")
+		if pattern, ok := n.Right.(*ast.TypePattern); ok {
+			// is 右侧的类型名
+			p.node(pattern.Name)
+			break
+		}
+		p.operand(n.Right)
+	case *ast.CompareExpr:
+		p.operand(n.Left)
+		p.write(" " + operator(n.Operator) + " ")
+		p.operand(n.Right)
+	case *ast.RangeExpr:
+		p.operand(n.Start)
+		if n.Inclusive {
+			p.write("..")
+		} else {
+			p.write("..<")
+		}
+		p.operand(n.End)
+		if n.Step != nil {
+			p.write(" step ")
+			p.operand(n.Step)
+		}
+	case *ast.AssignmentExpr:
+		p.operand(n.Left)
+		p.write(" " + operator(n.Operator) + " ")
+		p.operand(n.Right)
+	case *ast.TernaryExpr:
+		p.operand(n.Condition)
+		p.write(" ? ")
+		p.operand(n.Consequent)
+		p.write(" : ")
+		p.operand(n.Alternate)
+	case *ast.MemberExpr:
+		p.operand(n.Object)
+		switch {
+		case n.Optional:
+			p.write("?.")
+		case !n.Computed:
+			p.write(".")
+		}
+		if n.Computed {
+			p.write("[")
+			p.node(n.Property)
+			p.flush(span.End.Offset - 1)
+			p.write("]")
+		} else {
+			p.node(n.Property)
+		}
+	case *ast.CallExpr:
+		p.operand(n.Callee)
+		if n.Optional {
+			p.write("?.")
+		}
+		p.node(&n.Args)
+	case *ast.ChainExpr:
+		p.node(n.Expr)
+	case *ast.LambdaFunctionDecl:
+		p.write("fn")
+		p.node(&n.Args)
+		p.block(&n.Body)
+	case *ast.MatchExpr:
+		p.write("match ")
+		p.node(n.Subject)
+		p.write(":")
+		p.cases(n, nodes(n.Arms))
+	case *ast.CallTaskFn:
+		p.callTask(n)
+	default:
+		p.fail(node)
+	}
+	p.mark(span.End.Line)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"vine-lang/format"
+)
+
+// TestFormatExamples 测试所有示例格式化后语法树不变，且再次格式化结果相同
+func TestFormatExamples(t *testing.T) {
+	files, err := filepath.Glob("examples/**/*.vine")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, _ := filepath.Glob("examples/*.vine")
+	files = append(top, files...)
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := format.Source(file, content)
+		if err != nil {
+			t.Errorf("%s: %v", file, err)
+			continue
+		}
+		twice, err := format.Source(file, once)
+		if err != nil {
+			t.Errorf("%s: reformat: %v", file, err)
+			continue
+		}
+		if string(once) != string(twice) {
+			t.Errorf("%s: formatting is not idempotent:\n%s\n---\n%s", file, once, twice)
+		}
+	}
+}
+
+// TestFormatStyle 测试统一的书写风格
+func TestFormatStyle(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"fn a:\n  1\nend\n", "fn a():\n    1\nend\n"},
+		{"task fn b(x,y=2) :\n\tx\nend", "task fn b(x, y = 2):\n    x\nend\n"},
+		{"let a = [1 ,2]\nlet o = {a:1, \"k\":[ 2 ]}\n", "let a = [1, 2]\nlet o = {a: 1, \"k\": [2]}\n"},
+		{"switch a :\n  case 1,2 :\n    x\n  default:\n    y # z\nend\n", "switch a:\n    case 1, 2:\n        x\n    default:\n        y # z\nend\n"},
+		{"if a: 1 else: 2 end\nIF b:\n x\nELSE IF c:\n y\nEnd\n", "if a: 1 else: 2 end\nif b:\n    x\nelse if c:\n    y\nend\n"},
+		{"call() \n  to (res):\n    res\n  to ():\n    n;\n  catch (e):\n    e\nend\n", "call()\n    to (res):\n        res\n    to:\n        n\n    catch (e):\n        e\nend\n"},
+		{"let x = (a + b) * -(c)  # c\n\n\n## doc\ncst y = #[ b ]# 1\n", "let x = (a + b) * -(c) # c\n\n## doc\ncst y = #[ b ]# 1\n"},
+		{"let m = match v:\n case [x, ...r] if x>0 : x\n case {a, b: c}: c\nend\n", "let m = match v:\n    case [x, ...r] if x > 0: x\n    case {a, b as c}: c\nend\n"},
+		{"match v:\n case ^MAX,Color.RED: 1\n case lo..hi: 2\nend\n", "match v:\n    case ^MAX, Color.RED: 1\n    case lo..hi: 2\nend\n"},
+		{"print(x, # c\n  y)\n", "print(x, # c\n    y)\n"},
+		{"fn f():\n  let a = [1, # one\n 2, # two\n  3]\nend\n", "fn f():\n    let a = [1, # one\n        2, # two\n        3]\nend\n"},
+		{"let l = [\n 1, # one\n 2\n]\n", "let l = [\n    1, # one\n    2,\n]\n"},
+		{"outer : for k , v in 0..<10 step 2 : continue outer end\n", "outer: for k, v in 0..<10 step 2: continue outer end\n"},
+		{"type B( A ) :\n  let x=1\n  fn f( ) : self.x end\nend\n", "type B(A):\n    let x = 1\n    fn f(): self.x end\nend\n"},
+		{"let r = (1..3)\nprint((a), [(1)])\nlet s = (1) + (2 * 3)\n", "let r = 1..3\nprint(a, [1])\nlet s = (1) + (2 * 3)\n"},
+	}
+	for _, tt := range tests {
+		got, err := format.Source("style.vine", []byte(tt.src))
+		if err != nil {
+			t.Errorf("%q: %v", tt.src, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%q:\ngot:\n%s\nwant:\n%s", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"vine-lang/env"
+	"vine-lang/ipt"
+	"vine-lang/lexer"
+	"vine-lang/parser"
+	"vine-lang/token"
+	"vine-lang/verror"
+)
+
+// evalWith 执行 filename 中的代码并返回最后一条语句的值，vars 中的值预先定义在环境中
+func evalWith(t *testing.T, filename string, code string, vars map[string]any) any {
+	t.Helper()
+	lex := lexer.New(filename, code)
+	lex.Parse()
+	if err := lex.Err(); err != nil {
+		t.Fatalf("%q: %v", code, err)
+	}
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		t.Fatalf("%q: %v", code, err)
+	}
+	e := env.New(env.Workspace{Root: ".", BasePath: "."})
+	e.FileName = filename
+	for name, val := range vars {
+		e.DefineFast(name, val)
+	}
+	res, err := ipt.New(p, e).Eval(program, e)
+	if err != nil {
+		t.Fatalf("%q: %v", code, err)
+	}
+	return res
+}
+
+// runForError 执行 filename 中的代码并返回产生的错误，运行时错误以 panic 抛出
+func runForError(filename string, code string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ve, ok := r.(verror.InterpreterVError); ok {
+				err = ve
+				return
+			}
+			panic(r)
+		}
+	}()
+	_, err = executeCode(filename, code, env.Workspace{Root: ".", BasePath: "."})
+	return err
+}
+
+// lexErrors 对 filename 中的代码做词法分析，返回全部词法错误与非空白 token
+func lexErrors(filename string, code string) ([]verror.LexerVError, []token.Token) {
+	lex := lexer.New(filename, code)
+	lex.Parse()
+	var tokens []token.Token
+	for _, tk := range lex.Tokens() {
+		if tk.Type != token.WHITESPACE {
+			tokens = append(tokens, tk)
+		}
+	}
+	return lex.Errors(), tokens
+}
+
+// valueTest 一段代码与其最后一条语句的期望值
+type valueTest struct {
+	code string
+	want any
+}
+
+// checkValues 逐条执行代码并比较结果，数组按元素比较
+func checkValues(t *testing.T, filename string, tests []valueTest) {
+	t.Helper()
+	for _, tt := range tests {
+		if got := evalWith(t, filename, tt.code, nil); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q = %#v, want %#v", tt.code, got, tt.want)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package ipt
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"slices"
+	"strconv"
+	"strings"
 	"vine-lang/ast"
 	environment "vine-lang/env"
+	"vine-lang/libs/global"
+	"vine-lang/object/ranges"
 	"vine-lang/object/store"
 	"vine-lang/object/task"
 	"vine-lang/parser"
@@ -22,11 +28,14 @@ type Interpreter struct {
 }
 
 func New(p *parser.Parser, env *environment.Environment) *Interpreter {
-	return &Interpreter{
+	i := &Interpreter{
 		errors: make([]verror.InterpreterVError, 0),
 		p:      p,
 		env:    env,
 	}
+	// print 与模板字符串通过 __str__ 输出对象
+	env.Stringer = i.str
+	return i
 }
 
 func (i *Interpreter) Errorf(tk token.Token, format string) verror.InterpreterVError {
@@ -36,12 +45,34 @@ func (i *Interpreter) Errorf(tk token.Token, format string) verror.InterpreterVE
 	})
 }
 
+// ErrorAt 在节点的起始位置报告错误
+func (i *Interpreter) ErrorAt(node ast.Node, msg string) verror.InterpreterVError {
+	panic(i.locate(verror.InterpreterVError{Message: msg}, node))
+}
+
+// locate 为没有位置的错误补上节点的起始位置
+func (i *Interpreter) locate(err verror.InterpreterVError, node ast.Node) verror.InterpreterVError {
+	if node == nil || err.Line != 0 || node.NodeSpan().IsZero() {
+		return err
+	}
+	span := node.NodeSpan()
+	err.Filename = span.File
+	if err.Filename == "" {
+		err.Filename = i.env.FileName
+	}
+	err.Line, err.Column = span.Start.Line, span.Start.Column
+	return err
+}
+
 func (i *Interpreter) EvalProgramStmt(program *ast.ProgramStmt, env *environment.Environment) (any, error) {
 	var lastResult any
 	var err error
 	for _, s := range program.Body {
 		if _, ok := s.(*ast.CommentStmt); !ok {
 			lastResult, err = i.Eval(s, env)
+			if _, ok := err.(*loopControl); ok {
+				return nil, i.strayLoopControl(err)
+			}
 		}
 	}
 	return lastResult, err
@@ -53,13 +84,8 @@ func (i *Interpreter) EvalBlockStmt(node *ast.BlockStmt, env *environment.Enviro
 	for _, s := range node.Body {
 		if _, ok := s.(*ast.CommentStmt); !ok {
 			lastResult, err = i.Eval(s, env)
-			// 如果遇到 break 或 continue 语句，立即返回
+			// 出错或遇到 break/continue 时立即返回
 			if err != nil {
-				if vErr, ok := err.(verror.InterpreterVError); ok {
-					if vErr.Message == "break" || vErr.Message == "continue" {
-						return nil, err
-					}
-				}
 				return nil, err
 			}
 		}
@@ -72,7 +98,7 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 	if n.Source != nil && n.Source.Value != nil && (n.Source.Value.Type == token.IDENT || n.Source.Value.Type == token.STRING) {
 		s = *n.Source.Value
 	} else {
-		return nil, i.Errorf(token.Token{}, "Invalid module name")
+		return nil, i.ErrorAt(n, "Invalid module name")
 	}
 
 	mod, err := env.ImportModule(s.Value)
@@ -82,7 +108,7 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 
 	if n.Mode == token.AS {
 		if len(n.Specifiers) != 1 {
-			return nil, i.Errorf(token.Token{}, "use as requires exactly one alias")
+			return nil, i.ErrorAt(n, "use as requires exactly one alias")
 		}
 		if aliasLit, ok := n.Specifiers[0].(*ast.Literal); ok {
 			if aliasLit.Value.Type != token.IDENT {
@@ -92,10 +118,10 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 				// 使用DefineFast直接在当前环境定义别名，避免父环境干扰
 				env.DefineFast(aliasLit.Value.Value, mod)
 			} else {
-				return nil, i.Errorf(token.Token{Type: token.IDENT, Value: s.Value}, "module not found after import")
+				return nil, i.ErrorAt(n.Source, "module not found after import")
 			}
 		} else {
-			return nil, i.Errorf(token.Token{}, "invalid alias specifier")
+			return nil, i.ErrorAt(n.Specifiers[0], "invalid alias specifier")
 		}
 	} else if n.Mode == token.PICK {
 		if mod, ok := mod.(types.LibsModule); ok {
@@ -113,7 +139,7 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 				}
 				if us, ok := sp.(*ast.UseSpecifier); ok {
 					if us.Remote == nil || us.Remote.Value == nil || us.Remote.Value.Type != token.IDENT {
-						return nil, i.Errorf(token.Token{}, "invalid pick specifier")
+						return nil, i.ErrorAt(us, "invalid pick specifier")
 					}
 					var local token.Token
 					if us.Local != nil && us.Local.Value != nil {
@@ -131,10 +157,10 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 					}
 					continue
 				}
-				return nil, i.Errorf(token.Token{}, "invalid pick specifier")
+				return nil, i.ErrorAt(sp, "invalid pick specifier")
 			}
 		} else {
-			return nil, i.Errorf(token.Token{}, "invalid module type for pick")
+			return nil, i.ErrorAt(n.Source, "invalid module type for pick")
 		}
 	} else if n.Mode == token.USE {
 		if n.Source != nil && n.Source.Value != nil && n.Source.Value.Type == token.STRING {
@@ -149,7 +175,7 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 					env.DefineFast(tk.Value, val)
 				})
 			} else {
-				return nil, i.Errorf(token.Token{}, "invalid module type for use")
+				return nil, i.ErrorAt(n.Source, "invalid module type for use")
 			}
 		}
 	}
@@ -158,6 +184,19 @@ func (i *Interpreter) EvalUseDecl(n *ast.UseDecl, env *environment.Environment)
 
 func (i *Interpreter) EvalVariableDecl(n *ast.VariableDecl, env *environment.Environment) (any, error) {
 	val, err := i.Eval(n.Value, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.Pattern != nil {
+		i.bindPattern(n.Pattern, val, func(name token.Token, v any) {
+			if n.IsConst {
+				env.DefineConst(name, v)
+			} else {
+				env.SetFast(name.Value, v)
+			}
+		})
+		return val, nil
+	}
 	if n.IsConst {
 		env.DefineConst(*n.Name.Value, val)
 	} else {
@@ -167,6 +206,67 @@ func (i *Interpreter) EvalVariableDecl(n *ast.VariableDecl, env *environment.Env
 	return val, err
 }
 
+// bindPattern 按解构模式将值绑定到变量，define 负责定义单个变量
+// 结构不匹配时报错
+func (i *Interpreter) bindPattern(target ast.Expr, val any, define func(name token.Token, val any)) {
+	switch t := target.(type) {
+	case *ast.Literal:
+		define(*t.Value, val)
+	case *ast.ArrayPattern:
+		arr, ok := val.([]any)
+		if !ok {
+			i.ErrorAt(t, fmt.Sprintf("cannot destructure %s as array", typeName(val)))
+		}
+		if len(arr) < len(t.Elements) {
+			i.ErrorAt(t, fmt.Sprintf("not enough values to destructure: expected %d, got %d", len(t.Elements), len(arr)))
+		}
+		for index, el := range t.Elements {
+			i.bindPattern(el, arr[index], define)
+		}
+	case *ast.ObjectPattern:
+		for _, prop := range t.Properties {
+			key := *prop.Key.Value
+			var v any
+			var exists bool
+			switch obj := val.(type) {
+			case *store.StoreObject:
+				v, exists = obj.Get(key)
+			case types.LibsModule:
+				v, exists = obj.Get(key)
+			default:
+				i.Errorf(key, fmt.Sprintf("cannot destructure %s as object", typeName(val)))
+			}
+			if !exists {
+				i.Errorf(key, fmt.Sprintf("property %s not found in object", key.Value))
+			}
+			i.bindPattern(prop.Value, v, define)
+		}
+	default:
+		i.ErrorAt(target, "invalid destructuring target")
+	}
+}
+
+// patternNames 返回解构模式中绑定的所有变量名
+func patternNames(target ast.Expr) []token.Token {
+	switch t := target.(type) {
+	case *ast.Literal:
+		return []token.Token{*t.Value}
+	case *ast.ArrayPattern:
+		var names []token.Token
+		for _, el := range t.Elements {
+			names = append(names, patternNames(el)...)
+		}
+		return names
+	case *ast.ObjectPattern:
+		var names []token.Token
+		for _, prop := range t.Properties {
+			names = append(names, patternNames(prop.Value)...)
+		}
+		return names
+	}
+	return nil
+}
+
 func (i *Interpreter) EvalExposeStmt(n *ast.ExposeStmt, env *environment.Environment) (any, error) {
 	if env.Exports == nil {
 		env.Exports = store.NewStoreObject()
@@ -180,7 +280,7 @@ func (i *Interpreter) EvalExposeStmt(n *ast.ExposeStmt, env *environment.Environ
 		switch decl := n.Decl.(type) {
 		case *ast.FunctionDecl:
 			if decl.ID == nil || decl.ID.Value == nil {
-				return nil, i.Errorf(token.Token{}, "invalid expose function")
+				return nil, i.ErrorAt(decl, "invalid expose function")
 			}
 			val, exists := env.Get(*decl.ID.Value)
 			if !exists {
@@ -190,9 +290,24 @@ func (i *Interpreter) EvalExposeStmt(n *ast.ExposeStmt, env *environment.Environ
 				return nil, err
 			}
 			return val, nil
+		case *ast.TypeDecl:
+			val, _ := env.Get(*decl.ID.Value)
+			if err := env.Exports.Define(*decl.ID.Value, val); err != nil {
+				return nil, err
+			}
+			return val, nil
 		case *ast.VariableDecl:
+			if decl.Pattern != nil {
+				for _, name := range patternNames(decl.Pattern) {
+					val, _ := env.Get(name)
+					if err := env.Exports.Define(name, val); err != nil {
+						return nil, err
+					}
+				}
+				return nil, nil
+			}
 			if decl.Name.Value == nil {
-				return nil, i.Errorf(token.Token{}, "invalid expose variable")
+				return nil, i.ErrorAt(decl, "invalid expose variable")
 			}
 			val, exists := env.Get(*decl.Name.Value)
 			if !exists {
@@ -203,7 +318,7 @@ func (i *Interpreter) EvalExposeStmt(n *ast.ExposeStmt, env *environment.Environ
 			}
 			return val, nil
 		default:
-			return nil, i.Errorf(token.Token{}, "invalid expose declaration")
+			return nil, i.ErrorAt(n.Decl, "invalid expose declaration")
 		}
 	}
 
@@ -226,58 +341,88 @@ func (i *Interpreter) EvalExposeStmt(n *ast.ExposeStmt, env *environment.Environ
 		return val, nil
 	}
 
-	return nil, i.Errorf(token.Token{}, "invalid expose statement")
+	return nil, i.ErrorAt(n, "invalid expose statement")
 }
 
 func (i *Interpreter) EvalForStmt(n *ast.ForStmt, env *environment.Environment) (any, error) {
 	loopEnv := environment.New(env.WorkSpace)
 	loopEnv.Link(env)
 	if n.Range != nil && n.Init != nil {
-		name, ok := n.Init.(*ast.Literal)
-
-		if !ok {
-			return nil, i.Errorf(token.Token{}, "init must be a literal")
-		}
-
 		value, err := i.Eval(n.Range, loopEnv)
 		if err != nil {
 			return nil, err
 		}
 
-		if reflect.TypeOf(value).Kind() == reflect.Slice || reflect.TypeOf(value).Kind() == reflect.Array {
+		// keyAt 返回下标或对象的键，valueAt 返回元素
+		var length int
+		var keyAt, valueAt func(index int) any
+		keyAt = func(index int) any { return int64(index) }
+		if r, ok := value.(*ranges.RangeObject); ok {
+			// 区间按需计算元素
+			length = int(r.Len())
+			valueAt = func(index int) any { return r.At(int64(index)) }
+		} else if obj, ok := value.(*store.StoreObject); ok {
+			keys := obj.Keys()
+			length = len(keys)
+			keyAt = func(index int) any { return keys[index] }
+			valueAt = func(index int) any {
+				v, _ := obj.Get(token.Token{Type: token.IDENT, Value: keys[index]})
+				return v
+			}
+			// 单个循环变量遍历对象时得到键
+			if n.Key == nil {
+				valueAt = keyAt
+			}
+		} else if value != nil && (reflect.TypeOf(value).Kind() == reflect.Slice || reflect.TypeOf(value).Kind() == reflect.Array) {
 			valueOf := reflect.ValueOf(value)
-			length := valueOf.Len()
-			// 对于简单的循环体（不包含break/continue等），直接在loopEnv中执行
-			// 只有在需要隔离作用域时才创建新环境
-			simpleBody := isSimpleLoopBody(&n.Body)
-			nameToken := *name.Value
-
-			for index := 0; index < length; index++ {
-				if simpleBody {
-					// 简单循环体直接使用loopEnv，避免环境创建和释放
-					// 使用SetFast方法，避免Lookup开销
-					loopEnv.SetFast(nameToken.Value, valueOf.Index(index).Interface())
-					_, err = i.Eval(&n.Body, loopEnv)
-				} else {
-					// 复杂循环体需要隔离作用域
-					bodyEnv := environment.NewPooled(env.FileName)
-					bodyEnv.SetFast(nameToken.Value, valueOf.Index(index).Interface())
-					bodyEnv.Link(loopEnv)
-					_, err = i.Eval(&n.Body, bodyEnv)
-					bodyEnv.Release()
-				}
+			length = valueOf.Len()
+			valueAt = func(index int) any { return valueOf.Index(index).Interface() }
+		} else {
+			return nil, i.ErrorAt(n.Range, fmt.Sprintf("cannot iterate over %s", typeName(value)))
+		}
 
-				if err != nil {
-					// 检查是否是 break 或 continue 语句
-					if vErr, ok := err.(verror.InterpreterVError); ok {
-						if vErr.Message == "break" {
-							break
-						} else if vErr.Message == "continue" {
-							continue
-						}
+		// 绑定循环变量，单个标识符时走快速路径
+		name, isName := n.Init.(*ast.Literal)
+		bind := func(target *environment.Environment, index int) {
+			if n.Key != nil {
+				target.SetFast(n.Key.(*ast.Literal).Value.Value, keyAt(index))
+			}
+			if isName {
+				target.SetFast(name.Value.Value, valueAt(index))
+				return
+			}
+			i.bindPattern(n.Init, valueAt(index), func(tk token.Token, v any) {
+				target.SetFast(tk.Value, v)
+			})
+		}
+
+		// 对于简单的循环体（不包含break/continue等），直接在loopEnv中执行
+		// 只有在需要隔离作用域时才创建新环境
+		simpleBody := isSimpleLoopBody(&n.Body)
+
+		for index := 0; index < length; index++ {
+			if simpleBody {
+				// 简单循环体直接使用loopEnv，避免环境创建和释放
+				// 使用SetFast方法，避免Lookup开销
+				bind(loopEnv, index)
+				_, err = i.Eval(&n.Body, loopEnv)
+			} else {
+				// 复杂循环体需要隔离作用域
+				bodyEnv := environment.NewPooled(env.FileName)
+				bind(bodyEnv, index)
+				bodyEnv.Link(loopEnv)
+				_, err = i.Eval(&n.Body, bodyEnv)
+				bodyEnv.Release()
+			}
+
+			if err != nil {
+				if ctl, ok := loopSignal(err, n.Label); ok {
+					if ctl.kind == token.BREAK {
+						break
 					}
-					return nil, err
+					continue
 				}
+				return nil, err
 			}
 		}
 
@@ -299,7 +444,7 @@ func (i *Interpreter) EvalForStmt(n *ast.ForStmt, env *environment.Environment)
 				return nil, err
 			}
 
-			if isTrue, ok := condVal.(bool); !ok || !isTrue {
+			if !utils.IsTruthy(condVal) {
 				break
 			}
 		}
@@ -319,18 +464,16 @@ func (i *Interpreter) EvalForStmt(n *ast.ForStmt, env *environment.Environment)
 		}
 
 		if err != nil {
-			// 检查是否是 break 或 continue 语句
-			if vErr, ok := err.(verror.InterpreterVError); ok {
-				if vErr.Message == "break" {
+			if ctl, ok := loopSignal(err, n.Label); ok {
+				if ctl.kind == token.BREAK {
 					break
-				} else if vErr.Message == "continue" {
-					if n.Update != nil {
-						if _, err := i.Eval(n.Update, loopEnv); err != nil {
-							return nil, err
-						}
+				}
+				if n.Update != nil {
+					if _, err := i.Eval(n.Update, loopEnv); err != nil {
+						return nil, err
 					}
-					continue
 				}
+				continue
 			}
 			return nil, err
 		}
@@ -355,7 +498,7 @@ func isSimpleLoopBody(body *ast.BlockStmt) bool {
 		case *ast.VariableDecl:
 			// 如果有变量声明，需要隔离作用域
 			return false
-		case *ast.ForStmt, *ast.IfStmt:
+		case *ast.ForStmt, *ast.WhileStmt, *ast.IfStmt:
 			// 嵌套控制结构通常需要隔离作用域
 			return false
 		case *ast.FunctionDecl, *ast.LambdaFunctionDecl:
@@ -388,7 +531,7 @@ func (i *Interpreter) EvalIfStmt(n *ast.IfStmt, env *environment.Environment) (a
 		return nil, err
 	}
 
-	if isTrue, ok := condVal.(bool); !ok || !isTrue {
+	if !utils.IsTruthy(condVal) {
 		if n.Alternate != nil {
 			return i.Eval(n.Alternate, env)
 		}
@@ -423,6 +566,199 @@ func (i *Interpreter) EvalLambdaFunctionDecl(n *ast.LambdaFunctionDecl, env *env
 	}, nil
 }
 
+func (i *Interpreter) EvalMatchExpr(n *ast.MatchExpr, env *environment.Environment) (any, error) {
+	subject, err := i.Eval(n.Subject, env)
+	if err != nil {
+		return nil, err
+	}
+	for _, arm := range n.Arms {
+		for _, pattern := range arm.Patterns {
+			// 每个候选模式使用独立的作用域，匹配失败时不残留绑定
+			armEnv := environment.New(env.WorkSpace)
+			armEnv.Link(env)
+			ok, err := i.matchPattern(pattern, subject, armEnv)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if arm.Guard != nil {
+				guard, err := i.Eval(arm.Guard, armEnv)
+				if err != nil {
+					return nil, err
+				}
+				if !utils.IsTruthy(guard) {
+					continue
+				}
+			}
+			return i.Eval(arm.Body, armEnv)
+		}
+	}
+	return nil, i.ErrorAt(n, fmt.Sprintf("non-exhaustive match: no case matched %s", utils.TrasformPrintString(subject)))
+}
+
+// matchPattern 判断值是否匹配模式，匹配过程中将绑定写入 env
+func (i *Interpreter) matchPattern(pattern ast.Expr, val any, env *environment.Environment) (bool, error) {
+	switch p := pattern.(type) {
+	case *ast.Literal:
+		if p.Value.Type == token.IDENT {
+			if p.Value.Value != "_" {
+				env.DefinePassing(*p.Value, val)
+			}
+			return true, nil
+		}
+	case *ast.TypePattern:
+		return i.isType(p, val, env)
+	case *ast.PinPattern:
+		pattern = p.Name
+	case *ast.ArrayPattern:
+		arr, ok := val.([]any)
+		if !ok {
+			return false, nil
+		}
+		elements := p.Elements
+		var rest *ast.SpreadExpr
+		if len(elements) > 0 {
+			rest, _ = elements[len(elements)-1].(*ast.SpreadExpr)
+		}
+		if rest != nil {
+			elements = elements[:len(elements)-1]
+			if len(arr) < len(elements) {
+				return false, nil
+			}
+		} else if len(arr) != len(elements) {
+			return false, nil
+		}
+		for index, element := range elements {
+			if ok, err := i.matchPattern(element, arr[index], env); !ok || err != nil {
+				return false, err
+			}
+		}
+		if rest != nil {
+			env.DefinePassing(*rest.Value.(*ast.Literal).Value, slices.Clone(arr[len(elements):]))
+		}
+		return true, nil
+	case *ast.ObjectPattern:
+		var get func(token.Token) (any, bool)
+		switch obj := val.(type) {
+		case *store.StoreObject:
+			get = obj.Get
+		case types.LibsModule:
+			get = obj.Get
+		default:
+			return false, nil
+		}
+		for _, prop := range p.Properties {
+			key, _ := memberKey(prop.Key.Value.Value)
+			item, exists := get(key)
+			if !exists {
+				return false, nil
+			}
+			if ok, err := i.matchPattern(prop.Value, item, env); !ok || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// 值模式：区间匹配其中的整数，其余按值比较
+	expected, err := i.Eval(pattern, env)
+	if err != nil {
+		return false, err
+	}
+	if r, ok := expected.(*ranges.RangeObject); ok {
+		n, ok := val.(int64)
+		return ok && r.Contains(n), nil
+	}
+	return utils.EqualVal(expected, val), nil
+}
+
+// typeof 可能返回的类型名
+var typeNames = []string{"int", "float", "string", "bool", "nil", "array", "object", "function", "task", "module", "error", "range", "type"}
+
+// isType 判断值的类型名是否为 p，或值是否为名为 p 的用户类型的实例，类型名未知时报错
+func (i *Interpreter) isType(p *ast.TypePattern, val any, env *environment.Environment) (bool, error) {
+	name := p.Name.Value.Value
+	if slices.Contains(typeNames, name) {
+		return typeName(val) == name, nil
+	}
+	if t, ok := env.Get(*p.Name.Value); ok {
+		if t, ok := t.(*types.TypeValNode); ok {
+			return t.IsInstance(val), nil
+		}
+	}
+	return false, i.ErrorAt(p, fmt.Sprintf("unknown type name %q", name))
+}
+
+// typeName 返回值的类型名，即 typeof 的结果
+func typeName(val any) string {
+	switch v := val.(type) {
+	case nil:
+		return "nil"
+	case int64, int, int32, int16, int8, uint, uint64, uint32, uint16, uint8:
+		return "int"
+	case float64, float32:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case token.Token:
+		switch v.Type {
+		case token.NIL:
+			return "nil"
+		case token.TRUE, token.FALSE:
+			return "bool"
+		case token.INT:
+			return "int"
+		case token.FLOAT:
+			return "float"
+		case token.STRING:
+			return "string"
+		}
+		// 标识符 token 指向内置函数
+		return "function"
+	case []any:
+		return "array"
+	case *ranges.RangeObject:
+		return "range"
+	case *store.StoreObject:
+		return "object"
+	case *types.FunctionLikeValNode:
+		return "function"
+	case *types.TypeValNode:
+		return "type"
+	case *task.TaskObject:
+		return "task"
+	case types.LibsModule:
+		return "module"
+	case *types.ErrorValNode, error:
+		return "error"
+	}
+
+	// Go 原生值按种类归类
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Func:
+		return "function"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct, reflect.Pointer:
+		return "object"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	}
+	// 其余 Go 原生值视为对象
+	return "object"
+}
+
 func (i *Interpreter) EvalSwitchStmt(n *ast.SwitchStmt, env *environment.Environment) (any, error) {
 	condVal, err := i.Eval(n.Test, env)
 	if err != nil {
@@ -435,7 +771,7 @@ func (i *Interpreter) EvalSwitchStmt(n *ast.SwitchStmt, env *environment.Environ
 	for _, c := range n.Cases {
 		caseValue, ok := c.(*ast.SwitchCase)
 		if !ok {
-			return nil, i.Errorf(token.Token{}, "invalid switch case")
+			return nil, i.ErrorAt(c, "invalid switch case")
 		}
 		if caseValue != nil {
 			// 保存 default case 以便稍后处理
@@ -458,8 +794,8 @@ func (i *Interpreter) EvalSwitchStmt(n *ast.SwitchStmt, env *environment.Environ
 					matched = true
 					_, err = i.Eval(caseValue.Body, env)
 					if err != nil {
-						// 检查是否是 break 语句
-						if vErr, ok := err.(verror.InterpreterVError); ok && vErr.Message == "break" {
+						// 不带标签的 break 结束 switch
+						if ctl, ok := err.(*loopControl); ok && ctl.kind == token.BREAK && ctl.label == nil {
 							return nil, nil
 						}
 						return nil, err
@@ -474,8 +810,7 @@ func (i *Interpreter) EvalSwitchStmt(n *ast.SwitchStmt, env *environment.Environ
 	if !matched && defaultCase != nil {
 		_, err = i.Eval(defaultCase.Body, env)
 		if err != nil {
-			// 检查是否是 break 语句
-			if vErr, ok := err.(verror.InterpreterVError); ok && vErr.Message == "break" {
+			if ctl, ok := err.(*loopControl); ok && ctl.kind == token.BREAK && ctl.label == nil {
 				return nil, nil
 			}
 			return nil, err
@@ -485,20 +820,89 @@ func (i *Interpreter) EvalSwitchStmt(n *ast.SwitchStmt, env *environment.Environ
 	return nil, nil
 }
 
-func (i *Interpreter) EvalBreakStmt(n *ast.BreakStmt, env *environment.Environment) (any, error) {
-	// 返回特殊的错误类型来表示 break
-	return nil, verror.InterpreterVError{
-		Message:  "break",
-		Position: token.Token{}.ToPosition(env.FileName),
+// loopControl 表示 break/continue，沿求值结果向上传递到对应的循环
+type loopControl struct {
+	kind  token.TokenType
+	label *ast.Literal
+	node  ast.Node // 产生信号的 break/continue 语句，用于报告错误位置
+}
+
+func (l *loopControl) Error() string {
+	if l.label != nil {
+		return fmt.Sprintf("%s %s", strings.ToLower(string(l.kind)), l.label.Value.Value)
+	}
+	return strings.ToLower(string(l.kind))
+}
+
+// loopSignal 判断 err 是否是交给标签为 label 的循环处理的 break/continue
+func loopSignal(err error, label *ast.Literal) (*loopControl, bool) {
+	ctl, ok := err.(*loopControl)
+	if !ok {
+		return nil, false
+	}
+	if ctl.label != nil && (label == nil || ctl.label.Value.Value != label.Value.Value) {
+		return nil, false
 	}
+	return ctl, true
+}
+
+// strayLoopControl 将逃出循环的 break/continue 转换为运行时错误
+func (i *Interpreter) strayLoopControl(err error) error {
+	ctl, ok := err.(*loopControl)
+	if !ok {
+		return err
+	}
+	if ctl.label != nil {
+		return i.Errorf(*ctl.label.Value, fmt.Sprintf("undefined loop label: %s", ctl.label.Value.Value))
+	}
+	return i.ErrorAt(ctl.node, fmt.Sprintf("%s outside of loop", ctl.Error()))
+}
+
+func (i *Interpreter) EvalBreakStmt(n *ast.BreakStmt, env *environment.Environment) (any, error) {
+	return nil, &loopControl{kind: token.BREAK, label: n.Label, node: n}
 }
 
 func (i *Interpreter) EvalContinueStmt(n *ast.ContinueStmt, env *environment.Environment) (any, error) {
-	// 返回特殊的错误类型来表示 continue
-	return nil, verror.InterpreterVError{
-		Message:  "continue",
-		Position: token.Token{}.ToPosition(env.FileName),
+	return nil, &loopControl{kind: token.CONTINUE, label: n.Label, node: n}
+}
+
+func (i *Interpreter) EvalWhileStmt(n *ast.WhileStmt, env *environment.Environment) (any, error) {
+	loopEnv := environment.New(env.WorkSpace)
+	loopEnv.Link(env)
+	simpleBody := isSimpleLoopBody(&n.Body)
+
+	for {
+		if n.Test != nil {
+			cond, err := i.Eval(n.Test, loopEnv)
+			if err != nil {
+				return nil, err
+			}
+			if !utils.IsTruthy(cond) {
+				break
+			}
+		}
+
+		var err error
+		if simpleBody {
+			_, err = i.Eval(&n.Body, loopEnv)
+		} else {
+			bodyEnv := environment.NewPooled(env.FileName)
+			bodyEnv.Link(loopEnv)
+			_, err = i.Eval(&n.Body, bodyEnv)
+			bodyEnv.Release()
+		}
+
+		if err != nil {
+			if ctl, ok := loopSignal(err, n.Label); ok {
+				if ctl.kind == token.BREAK {
+					break
+				}
+				continue
+			}
+			return nil, err
+		}
 	}
+	return nil, nil
 }
 
 func (i *Interpreter) EvalTaskStmt(n *ast.TaskStmt, env *environment.Environment) (any, error) {
@@ -597,7 +1001,7 @@ func (i *Interpreter) EvalCallTaskFn(n *ast.CallTaskFn, env *environment.Environ
 		}).Run()
 		return nil, nil
 	}
-	return nil, i.Errorf(token.Token{}, "not a task function")
+	return nil, i.ErrorAt(&n.Target, "not a task function")
 }
 
 func (i *Interpreter) EvalToExpr(n *ast.ToExpr, env *environment.Environment) (any, error) {
@@ -640,21 +1044,158 @@ func (i *Interpreter) EvalToExpr(n *ast.ToExpr, env *environment.Environment) (a
 	return &toVal, nil
 }
 
+// 复合赋值运算符对应的二元运算符
+var compoundOperators = map[token.TokenType]token.TokenType{
+	token.INC_EQ:     token.PLUS,
+	token.DEC_EQ:     token.MINUS,
+	token.MUL_EQ:     token.MUL,
+	token.DIV_EQ:     token.DIV,
+	token.MOD_EQ:     token.MOD,
+	token.POW_EQ:     token.POW,
+	token.INT_DIV_EQ: token.INT_DIV,
+}
+
 func (i *Interpreter) EvalAssignmentExpr(n *ast.AssignmentExpr, env *environment.Environment) (any, error) {
-	operand, ok := n.Left.(*ast.Literal)
-	if !ok {
-		return nil, i.Errorf(n.Operator, "operand of assign must be a variable")
-	}
-	if operand.Value.Type != token.IDENT {
-		return nil, i.Errorf(*operand.Value, fmt.Sprintf("cannot increment non-variable (type %s)", operand.Value.String()))
+	if base, ok := compoundOperators[n.Operator.Type]; ok {
+		op := n.Operator
+		op.Type, op.Value = base, string(base)
+		_, val, err := i.update(n.Left, env, func(old any) (any, error) {
+			right, err := i.Eval(n.Right, env)
+			if err != nil {
+				return nil, err
+			}
+			return i.binaryOp(n, op, old, right, env)
+		})
+		return val, err
 	}
 
 	val, err := i.Eval(n.Right, env)
 	if err != nil {
 		return nil, err
 	}
-	env.SetFast(operand.Value.Value, val)
-	return nil, nil
+	if err := i.assign(n.Left, val, env); err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+// assign 将值写入赋值目标：变量、对象属性或数组元素
+func (i *Interpreter) assign(target ast.Expr, val any, env *environment.Environment) error {
+	switch t := target.(type) {
+	case *ast.Literal:
+		if t.Value.Type != token.IDENT {
+			return i.Errorf(*t.Value, fmt.Sprintf("cannot assign to %s", t.Value.Value))
+		}
+		env.Assign(*t.Value, val)
+		return nil
+	case *ast.MemberExpr:
+		obj, err := i.Eval(t.Object, env)
+		if err != nil {
+			return err
+		}
+		key, err := i.memberKeyOf(t, env)
+		if err != nil {
+			return err
+		}
+		return i.setMember(t, obj, key, val)
+	}
+	return i.ErrorAt(target, "invalid assignment target")
+}
+
+// setMember 写入对象属性或数组元素
+func (i *Interpreter) setMember(n ast.Node, obj any, key any, val any) error {
+	switch o := obj.(type) {
+	case *store.StoreObject:
+		tk, ok := memberKey(key)
+		if !ok {
+			return i.ErrorAt(n, fmt.Sprintf("invalid key type %s", typeName(key)))
+		}
+		o.Put(tk, val)
+		return nil
+	case []any:
+		index, ok := indexOf(key)
+		if !ok {
+			return i.ErrorAt(n, "index must be an integer")
+		}
+		if index < 0 || index >= int64(len(o)) {
+			return i.ErrorAt(n, fmt.Sprintf("index %d out of range [0, %d)", index, len(o)))
+		}
+		o[index] = val
+		return nil
+	case nil:
+		return i.ErrorAt(n, "cannot set property of nil")
+	}
+	return i.ErrorAt(n, fmt.Sprintf("cannot set property of %s", typeName(obj)))
+}
+
+// update 读取赋值目标的当前值，经 fn 计算后写回，目标对象只求值一次
+// 返回旧值与新值，用于复合赋值与自增自减
+func (i *Interpreter) update(target ast.Expr, env *environment.Environment, fn func(old any) (any, error)) (any, any, error) {
+	switch t := target.(type) {
+	case *ast.Literal:
+		if t.Value.Type != token.IDENT {
+			return nil, nil, i.Errorf(*t.Value, fmt.Sprintf("cannot assign to %s", t.Value.Value))
+		}
+		old, exists := env.Get(*t.Value)
+		if !exists {
+			return nil, nil, i.Errorf(*t.Value, fmt.Sprintf("undefined variable: %s", t.Value.Value))
+		}
+		val, err := fn(old)
+		if err != nil {
+			return nil, nil, err
+		}
+		env.Assign(*t.Value, val)
+		return old, val, nil
+	case *ast.MemberExpr:
+		obj, err := i.Eval(t.Object, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, err := i.memberKeyOf(t, env)
+		if err != nil {
+			return nil, nil, err
+		}
+		old, err := i.getMember(t, obj, key)
+		if err != nil {
+			return nil, nil, err
+		}
+		val, err := fn(old)
+		if err != nil {
+			return nil, nil, err
+		}
+		return old, val, i.setMember(t, obj, key, val)
+	}
+	return nil, nil, i.ErrorAt(target, "invalid assignment target")
+}
+
+// memberKeyOf 返回成员表达式的属性：计算属性返回求值结果，否则返回属性名 token
+func (i *Interpreter) memberKeyOf(n *ast.MemberExpr, env *environment.Environment) (any, error) {
+	if !n.Computed {
+		return *n.Property.(*ast.Literal).Value, nil
+	}
+	val, err := i.Eval(n.Property, env)
+	if err != nil {
+		return nil, err
+	}
+	if tk, ok := val.(*token.Token); ok {
+		return *tk, nil
+	}
+	return val, nil
+}
+
+// indexOf 将属性转换为数组下标
+func indexOf(key any) (int64, bool) {
+	switch v := key.(type) {
+	case int64:
+		return v, true
+	case token.Token:
+		if v.Type != token.INT {
+			return 0, false
+		}
+		index, err := v.GetInt()
+		return index, err == nil
+	}
+	return 0, false
 }
 
 func (i *Interpreter) EvalCompareExpr(n *ast.CompareExpr, env *environment.Environment) (any, error) {
@@ -743,6 +1284,11 @@ func (i *Interpreter) EvalCompareExpr(n *ast.CompareExpr, env *environment.Envir
 		}
 	}
 
+	// 用户类型的实例调用对应的比较方法
+	if result, ok, err := i.callOperator(n, n.Operator.Type, leftRaw, rightRaw, env); ok {
+		return result, err
+	}
+
 	// 其他情况使用通用的CompareVal处理
 	return utils.CompareVal(leftRaw, n.Operator.Type, rightRaw)
 }
@@ -752,15 +1298,45 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 	if err != nil {
 		return nil, i.Errorf(n.Operator, err.Error())
 	}
+
+	// 逻辑运算短路求值，返回决定结果的操作数
+	switch n.Operator.Type {
+	case token.AND:
+		if !utils.IsTruthy(leftRaw) {
+			return leftRaw, nil
+		}
+		return i.Eval(n.Right, env)
+	case token.OR:
+		if utils.IsTruthy(leftRaw) {
+			return leftRaw, nil
+		}
+		return i.Eval(n.Right, env)
+	case token.COALESCE:
+		if !utils.IsNil(leftRaw) {
+			return leftRaw, nil
+		}
+		return i.Eval(n.Right, env)
+	case token.IS:
+		return i.isType(n.Right.(*ast.TypePattern), leftRaw, env)
+	}
 	rightRaw, err := i.Eval(n.Right, env)
 	if err != nil {
 		return nil, i.Errorf(n.Operator, err.Error())
 	}
 
+	if n.Operator.Type == token.IN {
+		return i.evalIn(n, leftRaw, rightRaw)
+	}
+	return i.binaryOp(n, n.Operator, leftRaw, rightRaw, env)
+}
+
+// binaryOp 计算算术与位运算，供二元表达式和复合赋值共用
+func (i *Interpreter) binaryOp(n ast.Node, operator token.Token, leftRaw, rightRaw any, env *environment.Environment) (any, error) {
+
 	// 快速路径处理常见的整数运算，避免类型解析开销
 	if left, ok := leftRaw.(int64); ok {
 		if right, ok := rightRaw.(int64); ok {
-			switch n.Operator.Type {
+			switch operator.Type {
 			case token.PLUS:
 				return left + right, nil
 			case token.MINUS:
@@ -769,17 +1345,35 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 				return left * right, nil
 			case token.DIV:
 				if right == 0 {
-					return nil, i.Errorf(n.Operator, "division by zero")
+					return nil, i.Errorf(operator, "division by zero")
 				}
 				if left%right == 0 {
 					return left / right, nil
 				}
 				return float64(left) / float64(right), nil
+			case token.INT_DIV:
+				if right == 0 {
+					return nil, i.Errorf(operator, "division by zero")
+				}
+				return utils.FloorDivInt(left, right), nil
+			case token.MOD:
+				if right == 0 {
+					return nil, i.Errorf(operator, "modulo by zero")
+				}
+				return utils.FloorModInt(left, right), nil
+			case token.POW:
+				return utils.PowInt(left, right), nil
+			case token.BIT_AND:
+				return left & right, nil
+			case token.BIT_OR:
+				return left | right, nil
+			case token.BIT_XOR:
+				return left ^ right, nil
 			}
 		}
 		// 快速路径处理整数和浮点数的混合运算
 		if right, ok := rightRaw.(float64); ok {
-			switch n.Operator.Type {
+			switch operator.Type {
 			case token.PLUS:
 				return float64(left) + right, nil
 			case token.MINUS:
@@ -788,7 +1382,7 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 				return float64(left) * right, nil
 			case token.DIV:
 				if right == 0 {
-					return nil, i.Errorf(n.Operator, "division by zero")
+					return nil, i.Errorf(operator, "division by zero")
 				}
 				return float64(left) / right, nil
 			}
@@ -798,7 +1392,7 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 	// 快速路径处理常见的浮点数运算
 	if left, ok := leftRaw.(float64); ok {
 		if right, ok := rightRaw.(float64); ok {
-			switch n.Operator.Type {
+			switch operator.Type {
 			case token.PLUS:
 				return left + right, nil
 			case token.MINUS:
@@ -807,14 +1401,16 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 				return left * right, nil
 			case token.DIV:
 				if right == 0 {
-					return nil, i.Errorf(n.Operator, "division by zero")
+					return nil, i.Errorf(operator, "division by zero")
 				}
 				return left / right, nil
+			case token.POW:
+				return math.Pow(left, right), nil
 			}
 		}
 		// 快速路径处理浮点数和整数的混合运算
 		if right, ok := rightRaw.(int64); ok {
-			switch n.Operator.Type {
+			switch operator.Type {
 			case token.PLUS:
 				return left + float64(right), nil
 			case token.MINUS:
@@ -823,142 +1419,364 @@ func (i *Interpreter) EvalBinaryExpr(n *ast.BinaryExpr, env *environment.Environ
 				return left * float64(right), nil
 			case token.DIV:
 				if right == 0 {
-					return nil, i.Errorf(n.Operator, "division by zero")
+					return nil, i.Errorf(operator, "division by zero")
 				}
 				return left / float64(right), nil
 			}
 		}
 	}
 
+	// 用户类型的实例调用对应的运算符方法
+	if result, ok, err := i.callOperator(n, operator.Type, leftRaw, rightRaw, env); ok {
+		return result, err
+	}
+
 	// 其他情况使用通用的BinaryVal处理
-	result, err := utils.BinaryVal(leftRaw, n.Operator.Type, rightRaw)
-	return result, err
+	result, err := utils.BinaryVal(leftRaw, operator.Type, rightRaw)
+	if err != nil {
+		return nil, i.Errorf(operator, err.Error())
+	}
+	return result, nil
+}
+
+// evalIn 成员检测：数组判断元素，字符串判断子串，对象与模块判断键
+func (i *Interpreter) evalIn(n *ast.BinaryExpr, needle, haystack any) (any, error) {
+	switch h := haystack.(type) {
+	case *ranges.RangeObject:
+		v, ok := needle.(int64)
+		return ok && h.Contains(v), nil
+	case []any:
+		for _, item := range h {
+			if utils.EqualVal(needle, item) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case string:
+		s, ok := needle.(string)
+		if !ok {
+			return nil, i.Errorf(n.Operator, fmt.Sprintf("left operand of 'in' string must be a string, got %s", typeName(needle)))
+		}
+		return strings.Contains(h, s), nil
+	case *store.StoreObject:
+		key, ok := memberKey(needle)
+		if !ok {
+			return false, nil
+		}
+		_, exists := h.Get(key)
+		return exists, nil
+	case types.LibsModule:
+		key, ok := memberKey(needle)
+		if !ok {
+			return false, nil
+		}
+		_, exists := h.Get(key)
+		return exists, nil
+	}
+	return nil, i.Errorf(n.Operator, fmt.Sprintf("unsupported right operand for 'in': %s", typeName(haystack)))
+}
+
+// memberKey 将值转换为对象属性查找用的 token
+func memberKey(val any) (token.Token, bool) {
+	switch v := val.(type) {
+	case string:
+		return token.Token{Type: token.IDENT, Value: v}, true
+	case int64:
+		return token.Token{Type: token.INT, Value: strconv.FormatInt(v, 10)}, true
+	case token.Token:
+		return v, true
+	}
+	return token.Token{}, false
+}
+
+// EvalTernaryExpr 只求值被选中的分支
+func (i *Interpreter) EvalTernaryExpr(n *ast.TernaryExpr, env *environment.Environment) (any, error) {
+	cond, err := i.Eval(n.Condition, env)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsTruthy(cond) {
+		return i.Eval(n.Consequent, env)
+	}
+	return i.Eval(n.Alternate, env)
+}
+
+func (i *Interpreter) EvalRangeExpr(n *ast.RangeExpr, env *environment.Environment) (any, error) {
+	var bounds [3]int64
+	for index, expr := range []ast.Expr{n.Start, n.End, n.Step} {
+		if expr == nil {
+			continue
+		}
+		v, err := i.Eval(expr, env)
+		if err != nil {
+			return nil, err
+		}
+		num, ok := v.(int64)
+		if !ok {
+			return nil, i.ErrorAt(expr, fmt.Sprintf("range bounds must be integers, got %s", typeName(v)))
+		}
+		bounds[index] = num
+	}
+	r, err := ranges.New(bounds[0], bounds[1], bounds[2], n.Inclusive, n.Step != nil)
+	if err != nil {
+		return nil, i.ErrorAt(n, err.Error())
+	}
+	return r, nil
+}
+
+func (i *Interpreter) EvalTemplateLiteralExpr(n *ast.TemplateLiteralExpr, env *environment.Environment) (any, error) {
+	var sb strings.Builder
+	for _, q := range n.Quotes {
+		if el, ok := q.(*ast.TemplateElement); ok {
+			sb.WriteString(el.Value.Value.Value)
+			continue
+		}
+		v, err := i.Eval(q, env)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(global.ToString(env, v))
+	}
+	return sb.String(), nil
 }
 
 func (i *Interpreter) EvalArrayExpr(n *ast.ArrayExpr, env *environment.Environment) (any, error) {
-	var arr = make([]any, len(n.Items))
-	for index, element := range n.Items {
+	var arr = make([]any, 0, len(n.Items))
+	for _, element := range n.Items {
+		if spread, ok := element.Value.(*ast.SpreadExpr); ok {
+			items, err := i.evalSpread(spread, env)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, items...)
+			continue
+		}
 		v, err := i.Eval(element.Value, env)
 		if err != nil {
 			return nil, err
 		}
-		arr[index] = v
+		arr = append(arr, v)
 	}
 	return arr, nil
 }
 
+// evalSpread 展开数组或区间为值列表
+func (i *Interpreter) evalSpread(n *ast.SpreadExpr, env *environment.Environment) ([]any, error) {
+	val, err := i.Eval(n.Value, env)
+	if err != nil {
+		return nil, err
+	}
+	switch v := val.(type) {
+	case []any:
+		return v, nil
+	case *ranges.RangeObject:
+		items := make([]any, v.Len())
+		for index := range items {
+			items[index] = v.At(int64(index))
+		}
+		return items, nil
+	}
+	return nil, i.ErrorAt(n, fmt.Sprintf("cannot spread %s as a list", typeName(val)))
+}
+
 func (i *Interpreter) EvalObjectExpr(n *ast.ObjectExpr, env *environment.Environment) (any, error) {
 	obj := store.NewStoreObject()
-	obj.Define(token.Token{Type: token.IDENT, Value: "__proto__"}, store.NewStoreObject())
 	for _, prop := range n.Properties {
+		// 展开对象或模块导出，后出现的键覆盖先前的值
+		if spread, ok := prop.Value.(*ast.SpreadExpr); ok {
+			v, err := i.Eval(spread.Value, env)
+			if err != nil {
+				return nil, err
+			}
+			if err := i.spreadInto(spread, obj, v); err != nil {
+				return nil, err
+			}
+			continue
+		}
 		v, err := i.Eval(prop.Value, env)
 		if err != nil {
 			return nil, err
 		}
 		// 字符串和数字才可以当作key
 		if slices.Contains([]token.TokenType{token.STRING, token.INT, token.FLOAT, token.IDENT}, prop.Key.Value.Type) {
-			obj.Define(*prop.Key.Value, v)
+			obj.Put(*prop.Key.Value, v)
 		} else {
-			return nil, i.Errorf(token.Token{}, "invalid key type")
+			return nil, i.ErrorAt(prop.Key, "invalid key type")
 		}
 	}
 	return obj, nil
 }
 
+// spreadInto 将对象或模块导出的属性复制到 obj 中
+func (i *Interpreter) spreadInto(n *ast.SpreadExpr, obj *store.StoreObject, val any) error {
+	switch v := val.(type) {
+	case nil:
+		return nil
+	case *store.StoreObject:
+		for _, key := range v.Keys() {
+			tk := token.Token{Type: token.IDENT, Value: key}
+			item, _ := v.Get(tk)
+			obj.Put(tk, item)
+		}
+		return nil
+	case types.LibsModule:
+		v.ForEach(func(tk token.Token, item any) {
+			obj.Put(tk, item)
+		})
+		return nil
+	}
+	return i.ErrorAt(n, fmt.Sprintf("cannot spread %s into object", typeName(val)))
+}
+
 func (i *Interpreter) EvalMemberExpr(n *ast.MemberExpr, env *environment.Environment) (any, error) {
 	obj, err := i.Eval(n.Object, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.Optional && utils.IsNil(obj) {
+		return nil, errShortCircuit
+	}
 	if obj == nil {
-		return nil, i.Errorf(token.Token{}, "nil object")
+		return nil, i.ErrorAt(n.Object, "nil object")
 	}
+	prop, err := i.memberKeyOf(n, env)
 	if err != nil {
 		return nil, err
 	}
+	// 可选访问不存在的属性或越界的下标得到 nil
+	if n.Optional && !hasMember(obj, prop) {
+		return nil, nil
+	}
+	return i.getMember(n, obj, prop)
+}
 
-	var prop any
-	if n.Computed {
-		val, err := i.Eval(n.Property, env)
-		if err != nil {
-			return nil, err
-		}
+// errShortCircuit 可选链遇到 nil 时沿链向上传递，由 ChainExpr 转换为 nil
+var errShortCircuit = errors.New("optional chain short-circuit")
 
-		switch v := val.(type) {
-		case *token.Token:
-			prop = *v
-		case token.Token:
-			prop = v
-		default:
-			prop = v
+func (i *Interpreter) EvalChainExpr(n *ast.ChainExpr, env *environment.Environment) (any, error) {
+	val, err := i.Eval(n.Expr, env)
+	if err == errShortCircuit {
+		return nil, nil
+	}
+	return val, err
+}
+
+// hasMember 判断对象、模块或数组是否存在指定的属性或下标
+func hasMember(obj any, prop any) bool {
+	switch m := obj.(type) {
+	case *store.StoreObject:
+		key, ok := memberKey(prop)
+		if !ok {
+			return false
 		}
-	} else {
-		if ident, ok := n.Property.(*ast.Literal); ok {
-			prop = *ident.Value
-		} else {
-			if scope, ok := obj.(types.Scope); ok {
-				env.MountScope = scope
-				val, err := i.Eval(n.Property, env)
-				env.MountScope = nil
-				if err != nil {
-					return nil, err
-				}
-				return val, nil
-			} else {
-				return nil, i.Errorf(token.Token{}, "invalid property structure")
-			}
+		_, exists := m.Get(key)
+		return exists
+	case types.LibsModule:
+		key, ok := memberKey(prop)
+		if !ok {
+			return false
 		}
+		_, exists := m.Get(key)
+		return exists
+	case []any:
+		index, ok := indexOf(prop)
+		return ok && index >= 0 && index < int64(len(m))
 	}
+	return true
+}
 
+// getMember 读取对象属性、模块成员、数组元素或区间切片
+func (i *Interpreter) getMember(n ast.Node, obj any, prop any) (any, error) {
+	/* 区间切片 */
+	if r, ok := prop.(*ranges.RangeObject); ok {
+		return i.sliceByRange(n, obj, r)
+	}
+
+	switch m := obj.(type) {
 	/* 对象 */
-	if m, ok := obj.(*store.StoreObject); ok {
-		if p, ok := prop.(token.Token); ok {
-			if v, ok := m.Get(p); ok {
+	case *store.StoreObject:
+		if key, ok := memberKey(prop); ok {
+			if v, ok := m.Get(key); ok {
+				return bindMethod(v, m), nil
+			}
+		}
+		return nil, i.ErrorAt(n, fmt.Sprintf("property %s not found", utils.TrasformPrintString(prop)))
+	/* 父类型的方法 */
+	case *superRef:
+		if key, ok := memberKey(prop); ok {
+			if v, ok := m.proto.Get(key); ok {
+				return bindMethod(v, m.self), nil
+			}
+		}
+		return nil, i.ErrorAt(n, fmt.Sprintf("property %s not found in parent type", utils.TrasformPrintString(prop)))
+	/* 类型的原型，方法未绑定实例 */
+	case *types.TypeValNode:
+		if key, ok := memberKey(prop); ok {
+			if v, ok := m.Proto.Get(key); ok {
 				return v, nil
 			}
 		}
-	}
-
+		return nil, i.ErrorAt(n, fmt.Sprintf("property %s not found in type %s", utils.TrasformPrintString(prop), m.Name))
 	/* 模块 */
-	if m, ok := obj.(types.LibsModule); ok {
-		if v, ok := m.Get(prop.(token.Token)); ok {
-			return v, nil
+	case types.LibsModule:
+		if key, ok := memberKey(prop); ok {
+			if v, ok := m.Get(key); ok {
+				return v, nil
+			}
+		}
+		return nil, i.ErrorAt(n, fmt.Sprintf("property %s not found in module", utils.TrasformPrintString(prop)))
+	/* Slice or Array */
+	case []any:
+		index, ok := indexOf(prop)
+		if !ok {
+			return nil, i.ErrorAt(n, "index must be an integer")
+		}
+		if index < 0 || index >= int64(len(m)) {
+			return nil, i.ErrorAt(n, fmt.Sprintf("index %d out of range [0, %d)", index, len(m)))
 		}
+		return m[index], nil
 	}
 
-	/* Slice or Array */
-	if m, ok := obj.([]any); ok {
-		switch v := prop.(type) {
-		case int64:
-			if prop.(int64) >= int64(len(m)) {
-				return nil, i.Errorf(token.Token{}, "index out of range")
-			}
-			return m[prop.(int64)], nil
-		case token.Token:
-			if v.Type != token.INT {
-				return nil, i.Errorf(token.Token{}, "index must be an integer")
-			}
-			_i := prop.(token.Token)
-			if _i.Type == token.INT {
-				index, err := _i.GetInt()
-				if err != nil {
-					return nil, i.Errorf(token.Token{}, "index must be an integer")
-				}
-				if index >= int64(len(m)) {
-					return nil, i.Errorf(token.Token{}, "index out of range")
-				}
-				return m[index], nil
-			} else {
-				return nil, i.Errorf(token.Token{}, "index must be an integer")
+	// 强制转换 Go 结构体为 StoreObject
+	if t := reflect.TypeOf(obj); t.Kind() == reflect.Struct || (t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct) {
+		m := store.NewStoreObjectWithGoStruct(obj)
+		if key, ok := memberKey(prop); ok {
+			if v, ok := m.Get(key); ok {
+				return v, nil
 			}
 		}
 	}
 
-	// 强制转换对象为 StoreObject
-	m := store.NewStoreObjectWithGoStruct(obj)
+	return nil, i.ErrorAt(n, fmt.Sprintf("property %s not found", utils.TrasformPrintString(prop)))
+}
 
-	if v, ok := m.Get(prop.(token.Token)); ok {
-		return v, nil
+// sliceByRange 按区间截取数组或字符串，字符串按字符截取
+func (i *Interpreter) sliceByRange(n ast.Node, obj any, r *ranges.RangeObject) (any, error) {
+	length := r.Len()
+	switch v := obj.(type) {
+	case []any:
+		res := make([]any, 0, length)
+		for index := range length {
+			at := r.At(index)
+			if at < 0 || at >= int64(len(v)) {
+				return nil, i.ErrorAt(n, fmt.Sprintf("index %d out of range [0, %d)", at, len(v)))
+			}
+			res = append(res, v[at])
+		}
+		return res, nil
+	case string:
+		runes := []rune(v)
+		res := make([]rune, 0, length)
+		for index := range length {
+			at := r.At(index)
+			if at < 0 || at >= int64(len(runes)) {
+				return nil, i.ErrorAt(n, fmt.Sprintf("index %d out of range [0, %d)", at, len(runes)))
+			}
+			res = append(res, runes[at])
+		}
+		return string(res), nil
 	}
-
-	return nil, i.Errorf(token.Token{}, fmt.Sprintf("property %s not found", prop))
+	return nil, i.ErrorAt(n, fmt.Sprintf("cannot slice %s", typeName(obj)))
 }
 
 func (i *Interpreter) EvalArgsExpr(n *ast.ArgsExpr, env *environment.Environment) (any, error) {
@@ -973,67 +1791,210 @@ func (i *Interpreter) EvalArgsExpr(n *ast.ArgsExpr, env *environment.Environment
 }
 
 func (i *Interpreter) EvalCallExpr(n *ast.CallExpr, env *environment.Environment) (any, error) {
-	function, _ := i.Eval(n.Callee, env)
+	function, err := i.Eval(n.Callee, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.Optional && utils.IsNil(function) {
+		return nil, errShortCircuit
+	}
 
-	args := make([]any, len(n.Args.Arguments))
+	var args []any
+	var named []*ast.NamedArg
+	var namedVals []any
+	for _, arg := range n.Args.Arguments {
+		if na, ok := arg.(*ast.NamedArg); ok {
+			v, err := i.Eval(na.Value, env)
+			if err != nil {
+				return nil, err
+			}
+			named = append(named, na)
+			namedVals = append(namedVals, v)
+			continue
+		}
+		if spread, ok := arg.(*ast.SpreadExpr); ok {
+			items, err := i.evalSpread(spread, env)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, items...)
+			continue
+		}
+		v, err := i.Eval(arg, env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
 
-	for ind, arg := range n.Args.Arguments {
-		args[ind], _ = i.Eval(arg, env)
+	if fn, ok := function.(*types.FunctionLikeValNode); ok {
+		return i.callFunction(n, fn, args, named, namedVals, env)
+	}
+	if t, ok := function.(*types.TypeValNode); ok {
+		return i.construct(n, t, args, named, namedVals, env)
 	}
 
+	if len(named) > 0 {
+		return nil, i.Errorf(*named[0].Name.Value, "named arguments are not supported by native functions")
+	}
 	if fn, ok := function.(token.Token); ok {
 		return env.CallFunc(fn, args)
-	} else if fn, ok := function.(*types.FunctionLikeValNode); ok {
-		// 对于简单函数（不包含嵌套函数声明），使用池化的环境
-		newEnv := environment.NewPooled(env.FileName)
-		newEnv.Link(env) // 继承父环境
+	} else if reflect.ValueOf(function).Kind() == reflect.Func {
+		return env.CallFuncObject(function, args)
+	} else {
+		return nil, i.ErrorAt(n.Callee, "Not a function")
+	}
+}
 
-		for index, arg := range fn.Args.Arguments {
-			name, ok := arg.(*ast.Literal)
-			if !ok {
-				newEnv.Release()
-				return nil, i.Errorf(token.Token{}, "Not a valid variable to bind")
+// callFunction 调用脚本函数，方法调用时在函数环境中定义 self 与 super，参数错误报告在 n 上
+func (i *Interpreter) callFunction(n ast.Node, fn *types.FunctionLikeValNode, args []any, named []*ast.NamedArg, namedVals []any, env *environment.Environment) (any, error) {
+	// 对于简单函数（不包含嵌套函数声明），使用池化的环境
+	newEnv := environment.NewPooled(env.FileName)
+	newEnv.Link(env) // 继承父环境
+
+	if fn.Owner != nil {
+		defineReceiver(fn, newEnv)
+	}
+	if err := i.bindArgs(n, fn, args, named, namedVals, newEnv); err != nil {
+		newEnv.Release()
+		return nil, err
+	}
+
+	if fn.IsTask {
+		tk := task.NewTaskObject(func(args ...[]any) any {
+			res, err := i.Eval(fn.Body, newEnv)
+			if err != nil {
+				return err
 			}
-			if len(args) <= index {
-				newEnv.Release()
-				return nil, i.Errorf(token.Token{}, "Not enough arguments")
+			return res
+		})
+		tk.Run()
+		return tk, nil
+	} else {
+		res, err := i.Eval(fn.Body, newEnv)
+		newEnv.Release() // 释放环境到池中
+		if err != nil {
+			// break/continue 不能跨越函数边界
+			return nil, i.strayLoopControl(err)
+		}
+		return res, nil
+	}
+}
+
+// funcName 返回用于错误信息的函数名，匿名函数为 <lambda>
+func funcName(fn *types.FunctionLikeValNode) string {
+	if fn.IsLamda || fn.Token == nil || fn.Token.Value == "" {
+		return "<lambda>"
+	}
+	return fn.Token.Value
+}
+
+// paramName 返回参数名，解构参数返回其模式
+func paramName(param *ast.Parameter) string {
+	if lit, ok := param.Name.(*ast.Literal); ok {
+		return lit.Value.Value
+	}
+	return param.Name.String()
+}
+
+// bindArgs 将实参绑定到形参：先按位置，再按名称，缺省的参数取默认值，多余的位置参数收集到剩余参数中
+// 参数个数不匹配的错误报告在调用表达式上
+func (i *Interpreter) bindArgs(call ast.Node, fn *types.FunctionLikeValNode, args []any, named []*ast.NamedArg, namedVals []any, env *environment.Environment) error {
+	params := fn.Args.Arguments
+	vals := make([]any, len(params))
+	bound := make([]bool, len(params))
+
+	var rest *ast.Parameter
+	positional := len(params)
+	if len(params) > 0 && params[len(params)-1].(*ast.Parameter).Rest {
+		rest = params[len(params)-1].(*ast.Parameter)
+		positional--
+	}
+
+	for index, arg := range args {
+		if index >= positional {
+			if rest == nil {
+				return i.ErrorAt(call, fmt.Sprintf("too many arguments in call to %s: expected %d, got %d", funcName(fn), positional, len(args)))
 			}
-			newEnv.DefinePassing(*name.Value, args[index])
+			break
 		}
+		vals[index], bound[index] = arg, true
+	}
 
-		if fn.IsTask {
-			tk := task.NewTaskObject(func(args ...[]any) any {
-				res, err := i.Eval(fn.Body, newEnv)
-				if err != nil {
-					return err
-				}
-				return res
-			})
-			tk.Run()
-			return tk, nil
-		} else {
-			res, err := i.Eval(fn.Body, newEnv)
-			newEnv.Release() // 释放环境到池中
+	for index, na := range named {
+		name := na.Name.Value.Value
+		pos := slices.IndexFunc(params[:positional], func(param ast.Expr) bool {
+			lit, ok := param.(*ast.Parameter).Name.(*ast.Literal)
+			return ok && lit.Value.Value == name
+		})
+		if pos < 0 {
+			return i.Errorf(*na.Name.Value, fmt.Sprintf("unknown parameter %s in call to %s", name, funcName(fn)))
+		}
+		if bound[pos] {
+			return i.Errorf(*na.Name.Value, fmt.Sprintf("parameter %s of %s specified more than once", name, funcName(fn)))
+		}
+		vals[pos], bound[pos] = namedVals[index], true
+	}
+
+	// 依次绑定，默认值在函数环境中求值，可以引用前面的参数
+	for index, param := range params[:positional] {
+		param := param.(*ast.Parameter)
+		if !bound[index] {
+			if param.Default == nil {
+				return i.ErrorAt(call, fmt.Sprintf("missing argument for parameter %s in call to %s", paramName(param), funcName(fn)))
+			}
+			v, err := i.Eval(param.Default, env)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			return res, nil
+			vals[index] = v
 		}
-	} else if reflect.ValueOf(function).Kind() == reflect.Func {
-		return env.CallFuncObject(function, args)
-	} else {
-		return nil, i.Errorf(token.Token{}, "Not a function")
+		i.bindPattern(param.Name, vals[index], env.DefinePassing)
 	}
+
+	if rest != nil {
+		var extra = []any{}
+		if len(args) > positional {
+			extra = append(extra, args[positional:]...)
+		}
+		env.DefinePassing(*rest.Name.(*ast.Literal).Value, extra)
+	}
+	return nil
 }
 
 func (i *Interpreter) EvalUnaryExpr(n *ast.UnaryExpr, env *environment.Environment) (any, error) {
-	if n.Operator.Type == token.MINUS || n.Operator.Type == token.NOT {
+	if n.Operator.Type == token.TYPEOF {
+		val, err := i.Eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		return typeName(val), nil
+	}
+
+	if n.Operator.Type == token.BIT_NOT {
+		val, err := i.Eval(n.Value, env)
+		if err != nil {
+			return nil, err
+		}
+		if v, ok := val.(int64); ok {
+			return ^v, nil
+		}
+		if fn := specialMethod(val, "__invert__"); fn != nil {
+			return i.callFunction(n, fn, nil, nil, nil, env)
+		}
+		return nil, i.Errorf(n.Operator, fmt.Sprintf("invalid operation: ~ (non-integer type %s)", typeName(val)))
+	}
+
+	if n.Operator.Type == token.MINUS || n.Operator.Type == token.NOT || n.Operator.Type == token.BANG {
 		val, err := i.Eval(n.Value, env)
 		if err != nil {
 			return nil, err
 		}
 
 		if n.Operator.Type == token.MINUS {
+			if fn := specialMethod(val, "__neg__"); fn != nil {
+				return i.callFunction(n, fn, nil, nil, nil, env)
+			}
 			switch v := val.(type) {
 			case int64:
 				return -v, nil
@@ -1042,62 +2003,36 @@ func (i *Interpreter) EvalUnaryExpr(n *ast.UnaryExpr, env *environment.Environme
 			case int:
 				return -v, nil
 			default:
-				return nil, i.Errorf(n.Operator, fmt.Sprintf("invalid operation: - (non-numeric type %T)", v))
+				return nil, i.Errorf(n.Operator, fmt.Sprintf("invalid operation: - (non-numeric type %s)", typeName(v)))
 			}
 		} else {
-			if b, ok := val.(bool); ok {
-				return !b, nil
-			}
-			return nil, i.Errorf(n.Operator, fmt.Sprintf("invalid operation: ! (non-boolean type %T)", val))
+			return !utils.IsTruthy(val), nil
 		}
 	}
 
-	operand, ok := n.Value.(*ast.Literal)
-	if !ok {
-		return nil, i.Errorf(n.Operator, "operand of increment/decrement must be a variable")
-	}
-
-	if operand.Value.Type != token.IDENT {
-		return nil, i.Errorf(*operand.Value, fmt.Sprintf("cannot increment non-variable (type %s)", operand.Value.String()))
-	}
-
-	oldVal, exists := env.Get(*operand.Value)
-	if !exists {
-		return nil, i.Errorf(*operand.Value, fmt.Sprintf("undefined variable: %s", operand.Value.Value))
-	}
-
-	var newVal any
-
-	switch v := oldVal.(type) {
-	case int64:
-		if n.Operator.Type == token.INC {
-			newVal = v + 1
-		} else {
-			newVal = v - 1
-		}
-	case float64:
-		if n.Operator.Type == token.INC {
-			newVal = v + 1
-		} else {
-			newVal = v - 1
+	oldVal, newVal, err := i.update(n.Value, env, func(old any) (any, error) {
+		var delta int64 = 1
+		if n.Operator.Type == token.DEC {
+			delta = -1
 		}
-	case int:
-		if n.Operator.Type == token.INC {
-			newVal = v + 1
-		} else {
-			newVal = v - 1
+		switch v := old.(type) {
+		case int64:
+			return v + delta, nil
+		case float64:
+			return v + float64(delta), nil
+		case int:
+			return v + int(delta), nil
 		}
-	default:
-		return nil, i.Errorf(*operand.Value, fmt.Sprintf("invalid operation: %s (non-numeric type %T)", n.Operator.Value, v))
+		return nil, i.Errorf(n.Operator, fmt.Sprintf("invalid operation: %s (non-numeric type %s)", n.Operator.Value, typeName(old)))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	env.SetFast(operand.Value.Value, newVal)
-
 	if n.IsSuffix {
 		return oldVal, nil
-	} else {
-		return newVal, nil
 	}
+	return newVal, nil
 }
 
 func (i *Interpreter) EvalLiteral(n *ast.Literal, env *environment.Environment) (any, error) {
@@ -1158,73 +2093,101 @@ func (i *Interpreter) EvalLiteral(n *ast.Literal, env *environment.Environment)
 	return nil, i.Errorf(*n.Value, fmt.Sprintf("Unknown literal type: %s", n.Value.Type))
 }
 
-func (i *Interpreter) Eval(node ast.Node, env *environment.Environment) (any, error) {
-
+// Eval 求值节点，返回的错误没有位置时报告在最内层的节点上
+func (i *Interpreter) Eval(node ast.Node, env *environment.Environment) (result any, err error) {
 	switch node.NodeType() {
 	case ast.NodeTypeProgramStmt:
-		return i.EvalProgramStmt(node.(*ast.ProgramStmt), env)
+		result, err = i.EvalProgramStmt(node.(*ast.ProgramStmt), env)
 	case ast.NodeTypeBlockStmt:
-		return i.EvalBlockStmt(node.(*ast.BlockStmt), env)
+		result, err = i.EvalBlockStmt(node.(*ast.BlockStmt), env)
 	case ast.NodeTypeUseDecl:
-		return i.EvalUseDecl(node.(*ast.UseDecl), env)
+		result, err = i.EvalUseDecl(node.(*ast.UseDecl), env)
 	case ast.NodeTypeExpressionStmt:
-		return i.Eval(node.(*ast.ExpressionStmt).Expression, env)
+		result, err = i.Eval(node.(*ast.ExpressionStmt).Expression, env)
 	case ast.NodeTypeVariableDecl:
-		return i.EvalVariableDecl(node.(*ast.VariableDecl), env)
+		result, err = i.EvalVariableDecl(node.(*ast.VariableDecl), env)
 	case ast.NodeTypeExposeStmt:
-		return i.EvalExposeStmt(node.(*ast.ExposeStmt), env)
+		result, err = i.EvalExposeStmt(node.(*ast.ExposeStmt), env)
 	case ast.NodeTypeForStmt:
-		return i.EvalForStmt(node.(*ast.ForStmt), env)
+		result, err = i.EvalForStmt(node.(*ast.ForStmt), env)
 	case ast.NodeTypeIfStmt:
-		return i.EvalIfStmt(node.(*ast.IfStmt), env)
+		result, err = i.EvalIfStmt(node.(*ast.IfStmt), env)
 	case ast.NodeTypeFunctionDecl:
-		return i.EvalFunctionDecl(node.(*ast.FunctionDecl), env)
+		result, err = i.EvalFunctionDecl(node.(*ast.FunctionDecl), env)
 	case ast.NodeTypeLambdaFunctionDecl:
-		return i.EvalLambdaFunctionDecl(node.(*ast.LambdaFunctionDecl), env)
+		result, err = i.EvalLambdaFunctionDecl(node.(*ast.LambdaFunctionDecl), env)
 	case ast.NodeTypeReturnStmt:
-		return i.Eval(node.(*ast.ReturnStmt).Value, env)
+		result, err = i.Eval(node.(*ast.ReturnStmt).Value, env)
 	case ast.NodeTypeBreakStmt:
-		return i.EvalBreakStmt(node.(*ast.BreakStmt), env)
+		result, err = i.EvalBreakStmt(node.(*ast.BreakStmt), env)
 	case ast.NodeTypeContinueStmt:
-		return i.EvalContinueStmt(node.(*ast.ContinueStmt), env)
+		result, err = i.EvalContinueStmt(node.(*ast.ContinueStmt), env)
 	case ast.NodeTypeSwitchStmt:
-		return i.EvalSwitchStmt(node.(*ast.SwitchStmt), env)
+		result, err = i.EvalSwitchStmt(node.(*ast.SwitchStmt), env)
 	case ast.NodeTypeTaskStmt:
-		return i.EvalTaskStmt(node.(*ast.TaskStmt), env)
+		result, err = i.EvalTaskStmt(node.(*ast.TaskStmt), env)
 	case ast.NodeTypeWaitStmt:
-		return i.EvalWaitStmt(node.(*ast.WaitStmt), env)
+		result, err = i.EvalWaitStmt(node.(*ast.WaitStmt), env)
 	case ast.NodeTypeCallTaskFn:
-		return i.EvalCallTaskFn(node.(*ast.CallTaskFn), env)
+		result, err = i.EvalCallTaskFn(node.(*ast.CallTaskFn), env)
 	case ast.NodeTypeToExpr:
-		return i.EvalToExpr(node.(*ast.ToExpr), env)
+		result, err = i.EvalToExpr(node.(*ast.ToExpr), env)
 	case ast.NodeTypeAssignmentExpr:
-		return i.EvalAssignmentExpr(node.(*ast.AssignmentExpr), env)
+		result, err = i.EvalAssignmentExpr(node.(*ast.AssignmentExpr), env)
 	case ast.NodeTypeCompareExpr:
-		return i.EvalCompareExpr(node.(*ast.CompareExpr), env)
+		result, err = i.EvalCompareExpr(node.(*ast.CompareExpr), env)
 	case ast.NodeTypeBinaryExpr:
-		return i.EvalBinaryExpr(node.(*ast.BinaryExpr), env)
+		result, err = i.EvalBinaryExpr(node.(*ast.BinaryExpr), env)
 	case ast.NodeTypeProperty:
 		return node, nil
 	case ast.NodeTypeArrayExpr:
-		return i.EvalArrayExpr(node.(*ast.ArrayExpr), env)
+		result, err = i.EvalArrayExpr(node.(*ast.ArrayExpr), env)
 	case ast.NodeTypeObjectExpr:
-		return i.EvalObjectExpr(node.(*ast.ObjectExpr), env)
+		result, err = i.EvalObjectExpr(node.(*ast.ObjectExpr), env)
 	case ast.NodeTypeMemberExpr:
-		return i.EvalMemberExpr(node.(*ast.MemberExpr), env)
+		result, err = i.EvalMemberExpr(node.(*ast.MemberExpr), env)
 	case ast.NodeTypeArgsExpr:
-		return i.EvalArgsExpr(node.(*ast.ArgsExpr), env)
+		result, err = i.EvalArgsExpr(node.(*ast.ArgsExpr), env)
 	case ast.NodeTypeCallExpr:
-		return i.EvalCallExpr(node.(*ast.CallExpr), env)
+		result, err = i.EvalCallExpr(node.(*ast.CallExpr), env)
 	case ast.NodeTypeUnaryExpr:
-		return i.EvalUnaryExpr(node.(*ast.UnaryExpr), env)
+		result, err = i.EvalUnaryExpr(node.(*ast.UnaryExpr), env)
 	case ast.NodeTypeLiteral:
-		return i.EvalLiteral(node.(*ast.Literal), env)
+		result, err = i.EvalLiteral(node.(*ast.Literal), env)
+	case ast.NodeTypeTemplateLiteralExpr:
+		result, err = i.EvalTemplateLiteralExpr(node.(*ast.TemplateLiteralExpr), env)
+	case ast.NodeTypeTernaryExpr:
+		result, err = i.EvalTernaryExpr(node.(*ast.TernaryExpr), env)
+	case ast.NodeTypeRangeExpr:
+		result, err = i.EvalRangeExpr(node.(*ast.RangeExpr), env)
+	case ast.NodeTypeChainExpr:
+		result, err = i.EvalChainExpr(node.(*ast.ChainExpr), env)
+	case ast.NodeTypeMatchExpr:
+		result, err = i.EvalMatchExpr(node.(*ast.MatchExpr), env)
+	case ast.NodeTypeWhileStmt:
+		result, err = i.EvalWhileStmt(node.(*ast.WhileStmt), env)
+	case ast.NodeTypeTypeDecl:
+		result, err = i.EvalTypeDecl(node.(*ast.TypeDecl), env)
+	default:
+		eval, ok := evaluators[node.NodeType()]
+		if !ok {
+			return nil, i.ErrorAt(node, fmt.Sprintf("Unknown AST node type: %T", node))
+		}
+		result, err = eval(i, node, env)
+	}
+	if err != nil {
+		if ve, ok := err.(verror.InterpreterVError); ok && ve.Line == 0 {
+			err = i.locate(ve, node)
+		}
 	}
-	return nil, i.Errorf(token.Token{}, fmt.Sprintf("Unknown AST node type: %T", node))
+	return result, err
 }
 
 func (i *Interpreter) EvalSafe() (any, error) {
 	ast := i.p.ParseProgram()
+	if err := i.p.Err(); err != nil {
+		return nil, err
+	}
 	v, e := i.Eval(ast, i.env)
 	if e != nil {
 		return nil, e
@@ -0,0 +1,248 @@
+package ipt
+
+import (
+	"fmt"
+	"slices"
+	"vine-lang/ast"
+	environment "vine-lang/env"
+	"vine-lang/object/store"
+	"vine-lang/token"
+	"vine-lang/types"
+	"vine-lang/utils"
+)
+
+var (
+	selfToken  = token.Token{Type: token.IDENT, Value: "self"}
+	superToken = token.Token{Type: token.IDENT, Value: "super"}
+	initToken  = token.Token{Type: token.IDENT, Value: "init"}
+)
+
+// superRef 方法中的 super，从父类型的原型查找方法并绑定到当前实例
+type superRef struct {
+	self  any
+	proto *store.StoreObject
+}
+
+// EvalTypeDecl 声明类型：方法存放在类型的原型上，字段在构造实例时求值
+func (i *Interpreter) EvalTypeDecl(n *ast.TypeDecl, env *environment.Environment) (any, error) {
+	var super *types.TypeValNode
+	if n.Parent != nil {
+		parent, err := i.Eval(n.Parent, env)
+		if err != nil {
+			return nil, err
+		}
+		t, ok := parent.(*types.TypeValNode)
+		if !ok {
+			return nil, i.ErrorAt(n.Parent, fmt.Sprintf("cannot inherit from %s, expected a type", typeName(parent)))
+		}
+		super = t
+	}
+
+	t := types.NewTypeValNode(n.ID.Value.Value, super)
+	var declared []string
+	declare := func(name token.Token) error {
+		if slices.Contains(declared, name.Value) {
+			return i.Errorf(name, fmt.Sprintf("%s is already declared in type %s", name.Value, t.Name))
+		}
+		declared = append(declared, name.Value)
+		return nil
+	}
+	for _, stmt := range n.Body.Body {
+		switch member := stmt.(type) {
+		case *ast.VariableDecl:
+			if member.Pattern != nil {
+				return nil, i.ErrorAt(member.Pattern, "type fields cannot be destructured")
+			}
+			if err := declare(*member.Name.Value); err != nil {
+				return nil, err
+			}
+			t.Fields = append(t.Fields, member)
+		case *ast.FunctionDecl:
+			if err := declare(*member.ID.Value); err != nil {
+				return nil, err
+			}
+			t.Proto.Put(*member.ID.Value, method(t, member, false))
+		case *ast.TaskStmt:
+			if err := declare(*member.Fn.ID.Value); err != nil {
+				return nil, err
+			}
+			t.Proto.Put(*member.Fn.ID.Value, method(t, &member.Fn, true))
+		}
+	}
+
+	if err := env.Define(*n.ID.Value, t); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func method(owner *types.TypeValNode, fn *ast.FunctionDecl, isTask bool) *types.FunctionLikeValNode {
+	return &types.FunctionLikeValNode{
+		Token:  fn.ID.Value,
+		Args:   fn.Arguments,
+		Body:   fn.Body,
+		IsTask: isTask,
+		Owner:  owner,
+	}
+}
+
+// construct 创建类型的实例：由父类型到子类型依次初始化字段，再调用 init
+// 没有 init 时只能按名称为字段赋值
+func (i *Interpreter) construct(call *ast.CallExpr, t *types.TypeValNode, args []any, named []*ast.NamedArg, namedVals []any, env *environment.Environment) (any, error) {
+	obj := store.NewInstance(t.Proto)
+	if err := i.initFields(obj, t, env); err != nil {
+		return nil, err
+	}
+
+	if init, ok := t.Proto.Get(initToken); ok {
+		fn, ok := init.(*types.FunctionLikeValNode)
+		if !ok {
+			return nil, i.ErrorAt(call, fmt.Sprintf("init of type %s is not a function", t.Name))
+		}
+		if _, err := i.callFunction(call, fn.Bind(obj), args, named, namedVals, env); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+
+	if len(args) > 0 {
+		return nil, i.ErrorAt(call, fmt.Sprintf("type %s has no init method, fields must be passed by name", t.Name))
+	}
+	fields := obj.Keys()
+	for index, na := range named {
+		if !slices.Contains(fields, na.Name.Value.Value) {
+			return nil, i.Errorf(*na.Name.Value, fmt.Sprintf("unknown field %s in call to %s", na.Name.Value.Value, t.Name))
+		}
+		obj.Put(*na.Name.Value, namedVals[index])
+	}
+	return obj, nil
+}
+
+// initFields 为实例定义类型及其父类型声明的字段，子类型的默认值覆盖父类型
+func (i *Interpreter) initFields(obj *store.StoreObject, t *types.TypeValNode, env *environment.Environment) error {
+	if t.Super != nil {
+		if err := i.initFields(obj, t.Super, env); err != nil {
+			return err
+		}
+	}
+	for _, field := range t.Fields {
+		val, err := i.Eval(field.Value, env)
+		if err != nil {
+			return err
+		}
+		obj.Put(*field.Name.Value, val)
+	}
+	return nil
+}
+
+// bindMethod 从对象上取出的方法绑定到该对象，其余值原样返回
+func bindMethod(val any, self any) any {
+	if fn, ok := val.(*types.FunctionLikeValNode); ok && fn.Owner != nil {
+		return fn.Bind(self)
+	}
+	return val
+}
+
+// defineReceiver 在方法的环境中定义 self 与 super，没有父类型时 super 为 nil
+func defineReceiver(fn *types.FunctionLikeValNode, env *environment.Environment) {
+	env.DefinePassing(selfToken, fn.Self)
+	var super any
+	if fn.Owner.Super != nil {
+		super = &superRef{self: fn.Self, proto: fn.Owner.Super.Proto}
+	}
+	env.DefinePassing(superToken, super)
+}
+
+// 运算符对应的特殊方法，左操作数没有该方法时以左操作数为参数调用右操作数的反射方法
+// 比较的反射方法为交换操作数后的比较，例如 a < b 反射为 b > a
+var operatorMethods = map[token.TokenType][2]string{
+	token.PLUS:       {"__add__", "__radd__"},
+	token.MINUS:      {"__sub__", "__rsub__"},
+	token.MUL:        {"__mul__", "__rmul__"},
+	token.DIV:        {"__div__", "__rdiv__"},
+	token.INT_DIV:    {"__floordiv__", "__rfloordiv__"},
+	token.MOD:        {"__mod__", "__rmod__"},
+	token.POW:        {"__pow__", "__rpow__"},
+	token.BIT_AND:    {"__and__", "__rand__"},
+	token.BIT_OR:     {"__or__", "__ror__"},
+	token.BIT_XOR:    {"__xor__", "__rxor__"},
+	token.SHL:        {"__lshift__", "__rlshift__"},
+	token.SHR:        {"__rshift__", "__rrshift__"},
+	token.EQ:         {"__eq__", "__eq__"},
+	token.NOT_EQ:     {"__ne__", "__ne__"},
+	token.LESS:       {"__lt__", "__gt__"},
+	token.LESS_EQ:    {"__le__", "__ge__"},
+	token.GREATER:    {"__gt__", "__lt__"},
+	token.GREATER_EQ: {"__ge__", "__le__"},
+}
+
+// callOperator 操作数为定义了对应特殊方法的对象时调用该方法，没有可调用的方法时返回 false
+// 没有 __ne__ 时对 __eq__ 的结果取反
+func (i *Interpreter) callOperator(n ast.Node, op token.TokenType, left, right any, env *environment.Environment) (any, bool, error) {
+	names, ok := operatorMethods[op]
+	if !ok {
+		return nil, false, nil
+	}
+	if fn := specialMethod(left, names[0]); fn != nil {
+		res, err := i.callFunction(n, fn, []any{right}, nil, nil, env)
+		return res, true, err
+	}
+	if fn := specialMethod(right, names[1]); fn != nil {
+		res, err := i.callFunction(n, fn, []any{left}, nil, nil, env)
+		return res, true, err
+	}
+	if op == token.NOT_EQ {
+		res, ok, err := i.callOperator(n, token.EQ, left, right, env)
+		if ok && err == nil {
+			return !utils.IsTruthy(res), true, nil
+		}
+		return res, ok, err
+	}
+	// 对象没有对应的方法时不能参与运算，相等比较仍按值进行
+	_, leftObj := left.(*store.StoreObject)
+	_, rightObj := right.(*store.StoreObject)
+	if op != token.EQ && (leftObj || rightObj) {
+		return nil, true, i.ErrorAt(n, fmt.Sprintf("unsupported operand types for %s: %s and %s, define %s to support it",
+			op, operandName(left), operandName(right), names[0]))
+	}
+	return nil, false, nil
+}
+
+// operandName 返回错误信息中操作数的类型，用户类型的实例返回类型名
+func operandName(val any) string {
+	if obj, ok := val.(*store.StoreObject); ok && obj.TypeName() != "" {
+		return obj.TypeName()
+	}
+	return typeName(val)
+}
+
+// specialMethod 返回对象上名为 name 的方法，已绑定到该对象；val 不是对象或没有该方法时返回 nil
+func specialMethod(val any, name string) *types.FunctionLikeValNode {
+	obj, ok := val.(*store.StoreObject)
+	if !ok {
+		return nil
+	}
+	method, ok := obj.Get(token.Token{Type: token.IDENT, Value: name})
+	if !ok {
+		return nil
+	}
+	fn, _ := bindMethod(method, obj).(*types.FunctionLikeValNode)
+	return fn
+}
+
+// str 在 env 中调用对象的 __str__ 方法得到其字符串形式，没有该方法时返回 false
+func (i *Interpreter) str(obj *store.StoreObject, env *environment.Environment) (string, bool) {
+	fn := specialMethod(obj, "__str__")
+	if fn == nil {
+		return "", false
+	}
+	res, err := i.callFunction(fn.Body, fn, nil, nil, nil, env)
+	if err != nil {
+		panic(err)
+	}
+	s, ok := res.(string)
+	if !ok {
+		i.ErrorAt(fn.Body, fmt.Sprintf("__str__ must return a string, got %s", typeName(res)))
+	}
+	return s, true
+}
@@ -0,0 +1,34 @@
+package ipt
+
+import (
+	"vine-lang/ast"
+	environment "vine-lang/env"
+	"vine-lang/parser"
+	"vine-lang/token"
+)
+
+// Evaluator 扩展节点的求值函数
+type Evaluator func(i *Interpreter, node ast.Node, env *environment.Environment) (any, error)
+
+var evaluators = map[ast.NodeType]Evaluator{}
+
+// RegisterEvaluator 为扩展节点类型注册求值函数，Eval 遇到内置类型以外的节点时调用
+func RegisterEvaluator(t ast.NodeType, eval Evaluator) {
+	evaluators[t] = eval
+}
+
+// RegisterSyntax 注册一条完整的扩展语句：关键字、解析函数与求值函数
+//
+//	var routeType = ast.RegisterNodeType("RouteStmt")
+//	ipt.RegisterSyntax("route", routeType, parseRoute, evalRoute)
+//
+// parse 在当前 token 为该关键字时调用，需要自行消费关键字并返回 routeType 类型的节点；
+// 应在初始化阶段调用
+func RegisterSyntax(keyword string, nodeType ast.NodeType, parse func(p *parser.Parser) ast.Stmt, eval Evaluator) token.TokenType {
+	kw := token.RegisterKeyword(keyword)
+	parser.RegisterSyntax(kw, func(p *parser.Parser) any {
+		return parse(p)
+	})
+	RegisterEvaluator(nodeType, eval)
+	return kw
+}
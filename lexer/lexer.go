@@ -2,6 +2,7 @@ package lexer
 
 import (
 	"fmt"
+	"strings"
 	"unicode/utf8"
 	"vine-lang/token"
 	"vine-lang/utils"
@@ -14,13 +15,17 @@ type Lexer struct {
 	column   int // current column in input
 	line     int // current line in input
 	ch       rune
-	chWidth  int           // width of current rune in bytes
-	tokens   []token.Token // list of tokens
+	chWidth  int                  // width of current rune in bytes
+	tokens   []token.Token        // list of tokens
+	errors   []verror.LexerVError // 收集所有错误
 	filename string
+
+	templateBraces []int     // 模板字符串插值中未闭合的花括号数量，栈顶为当前插值
+	cursor         token.Pos // 已计算到的源码位置，用于为 token 标注起止位置
 }
 
 func New(filename string, input string) *Lexer {
-	l := &Lexer{input: input, filename: filename, line: 1, position: 0}
+	l := &Lexer{input: input, filename: filename, line: 1, position: 0, cursor: token.Pos{Line: 1, Column: 1}}
 	l.readChar() // Initialize l.ch to the first character
 	return l
 }
@@ -58,6 +63,32 @@ func (l *Lexer) peekRune() rune {
 	return r
 }
 
+// peekRuneAt 查看当前字符之后第 n 个字符，不移动指针
+func (l *Lexer) peekRuneAt(n int) rune {
+	pos := l.position
+	width := l.chWidth
+	for ; n > 0; n-- {
+		pos += width
+		if pos >= len(l.input) {
+			return 0
+		}
+		_, width = utf8.DecodeRuneInString(l.input[pos:])
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[pos:])
+	return r
+}
+
+func (l *Lexer) errorAt(line, column int, msg string) *verror.LexerVError {
+	return &verror.LexerVError{
+		Position: verror.Position{
+			Filename: l.filename,
+			Line:     line,
+			Column:   column,
+		},
+		Message: msg,
+	}
+}
+
 func (l *Lexer) readIdentifier() string {
 	position := l.position
 	for utils.IsIdentifier(l.ch) {
@@ -66,44 +97,140 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() (string, bool) {
+// readNumber 读取数字字面量
+// 支持 0x/0o/0b 前缀、'_' 分隔符、小数和科学计数法，格式错误时返回 LexerVError
+func (l *Lexer) readNumber() (token.Token, error) {
+	tok := token.Token{Type: token.INT, Line: l.line, Column: l.column}
 	position := l.position
-	hasDecimal := false
-	for utils.IsDigitOrDot(l.ch) {
-		if l.ch == '.' {
-			if hasDecimal {
-				break
+	isPrefixed := l.ch == '0' && strings.ContainsRune("xXoObB", l.peekRune())
+	malformed := false
+
+	for {
+		switch {
+		case utils.IsIdentifier(l.ch):
+			// 数字、字母与 '_' 统一读入，由后续解析校验
+			if !isPrefixed && (l.ch == 'e' || l.ch == 'E') {
+				tok.Type = token.FLOAT
+				if next := l.peekRune(); next == '+' || next == '-' {
+					l.readChar()
+				}
 			}
-			hasDecimal = true
+			l.readChar()
+			continue
+		case l.ch == '.' && utils.IsDigit(l.peekRune()):
+			// 只有 '.' 后紧跟数字时才视为小数点，保证 1..10、a.0 等写法正常
+			if tok.Type == token.FLOAT {
+				malformed = true
+			}
+			tok.Type = token.FLOAT
+			l.readChar()
+			continue
 		}
-		l.readChar()
+		break
+	}
+
+	tok.Value = l.input[position:l.position]
+	if !malformed {
+		var err error
+		if tok.Type == token.FLOAT {
+			_, err = tok.GetFloat()
+		} else {
+			_, err = tok.GetInt()
+		}
+		malformed = err != nil
 	}
-	numStr := l.input[position:l.position]
-	return numStr, hasDecimal
+	if malformed {
+		return tok, l.errorAt(tok.Line, tok.Column, fmt.Sprintf("malformed number literal: %s", tok.Value))
+	}
+	return tok, nil
 }
 
-func (l *Lexer) GetToken() (token.Token, error) {
-	var tok token.Token
+// readComment 读取注释
+// # 为行注释，## 为文档注释，#[ ... ]# 为可嵌套的块注释
+func (l *Lexer) readComment() (token.Token, error) {
+	tok := token.Token{Type: token.COMMENT, Line: l.line, Column: l.column}
+	l.readChar() // 跳过 '#'
+
 	switch l.ch {
+	case '[':
+		return l.readBlockComment(tok)
 	case '#':
+		tok.Type = token.DOC_COMMENT
+		l.readChar()
+	}
+
+	pos := l.position
+	for l.ch != '\n' && !l.isEof() {
 		l.readChar()
-		pos := l.position
-		for l.ch != '\n' && !l.isEof() {
+	}
+	tok.Value = strings.TrimSuffix(l.input[pos:l.position], "\r")
+	return tok, nil
+}
+
+func (l *Lexer) readBlockComment(tok token.Token) (token.Token, error) {
+	tok.Type = token.BLOCK_COMMENT
+	l.readChar() // 跳过 '['
+	pos := l.position
+	depth := 1
+	for {
+		if l.isEof() {
+			return tok, l.errorAt(tok.Line, tok.Column, "unterminated block comment")
+		}
+		if l.ch == '#' && l.peekRune() == '[' {
+			depth++
+			l.readChar()
+		} else if l.ch == ']' && l.peekRune() == '#' {
+			depth--
+			if depth == 0 {
+				break
+			}
 			l.readChar()
 		}
-		tok.Value = l.input[pos:l.position]
-		tok.Column = l.column
-		tok.Line = l.line
-		tok.Type = token.COMMENT
-		return tok, nil
+		l.skipStringChar()
+	}
+	tok.Value = l.input[pos:l.position]
+	l.readChar()
+	l.readChar()
+	return tok, nil
+}
+
+func (l *Lexer) GetToken() (token.Token, error) {
+	var tok token.Token
+	switch l.ch {
+	case '#':
+		return l.readComment()
 	case ',':
 		tok = token.NewToken(token.COMMA, l.ch, l.column, l.line)
 	case ':':
 		tok = token.NewToken(token.COLON, l.ch, l.column, l.line)
 	case '.':
-		tok = token.NewToken(token.DOT, l.ch, l.column, l.line)
+		if l.peekRune() == '.' {
+			tok = token.NewTokenDuplicated(token.DOTDOT, l.ch, l.column, l.line, '.')
+			l.readChar()
+			switch l.peekRune() {
+			case '<':
+				tok.Type = token.DOTDOT_LT
+				tok.Value = "..<"
+				l.readChar()
+			case '.':
+				tok.Type = token.ELLIPSIS
+				tok.Value = "..."
+				l.readChar()
+			}
+		} else {
+			tok = token.NewToken(token.DOT, l.ch, l.column, l.line)
+		}
 	case '?':
-		tok = token.NewToken(token.QUESTION, l.ch, l.column, l.line)
+		switch next := l.peekRune(); {
+		case next == '?':
+			tok = token.NewTokenDuplicated(token.COALESCE, l.ch, l.column, l.line, next)
+			l.readChar()
+		case next == '.':
+			tok = token.NewTokenDuplicated(token.OPT_CHAIN, l.ch, l.column, l.line, next)
+			l.readChar()
+		default:
+			tok = token.NewToken(token.QUESTION, l.ch, l.column, l.line)
+		}
 	case '+':
 		peek := l.peekRune()
 		switch peek {
@@ -131,6 +258,13 @@ func (l *Lexer) GetToken() (token.Token, error) {
 	case '*':
 		peek := l.peekRune()
 		switch peek {
+		case '*':
+			tok = token.NewTokenDuplicated(token.POW, l.ch, l.column, l.line, peek)
+			l.readChar()
+			if l.peekRune() == '=' {
+				tok.Type, tok.Value = token.POW_EQ, "**="
+				l.readChar()
+			}
 		case '=':
 			tok = token.NewTokenDuplicated(token.MUL_EQ, l.ch, l.column, l.line, peek)
 			l.readChar()
@@ -140,12 +274,47 @@ func (l *Lexer) GetToken() (token.Token, error) {
 	case '/':
 		peek := l.peekRune()
 		switch peek {
+		case '/':
+			tok = token.NewTokenDuplicated(token.INT_DIV, l.ch, l.column, l.line, peek)
+			l.readChar()
+			if l.peekRune() == '=' {
+				tok.Type, tok.Value = token.INT_DIV_EQ, "//="
+				l.readChar()
+			}
 		case '=':
 			tok = token.NewTokenDuplicated(token.DIV_EQ, l.ch, l.column, l.line, peek)
 			l.readChar()
 		default:
 			tok = token.NewToken(token.DIV, l.ch, l.column, l.line)
 		}
+	case '%':
+		peek := l.peekRune()
+		if peek == '=' {
+			tok = token.NewTokenDuplicated(token.MOD_EQ, l.ch, l.column, l.line, peek)
+			l.readChar()
+		} else {
+			tok = token.NewToken(token.MOD, l.ch, l.column, l.line)
+		}
+	case '&':
+		peek := l.peekRune()
+		if peek == '&' {
+			tok = token.NewTokenDuplicated(token.AND, l.ch, l.column, l.line, peek)
+			l.readChar()
+		} else {
+			tok = token.NewToken(token.BIT_AND, l.ch, l.column, l.line)
+		}
+	case '|':
+		peek := l.peekRune()
+		if peek == '|' {
+			tok = token.NewTokenDuplicated(token.OR, l.ch, l.column, l.line, peek)
+			l.readChar()
+		} else {
+			tok = token.NewToken(token.BIT_OR, l.ch, l.column, l.line)
+		}
+	case '^':
+		tok = token.NewToken(token.BIT_XOR, l.ch, l.column, l.line)
+	case '~':
+		tok = token.NewToken(token.BIT_NOT, l.ch, l.column, l.line)
 	case '=':
 		peek := l.peekRune()
 		if peek == '=' {
@@ -164,18 +333,26 @@ func (l *Lexer) GetToken() (token.Token, error) {
 		}
 	case '<':
 		peek := l.peekRune()
-		if peek == '=' {
+		switch peek {
+		case '=':
 			tok = token.NewTokenDuplicated(token.LESS_EQ, l.ch, l.column, l.line, peek)
 			l.readChar()
-		} else {
+		case '<':
+			tok = token.NewTokenDuplicated(token.SHL, l.ch, l.column, l.line, peek)
+			l.readChar()
+		default:
 			tok = token.NewToken(token.LESS, l.ch, l.column, l.line)
 		}
 	case '>':
 		peek := l.peekRune()
-		if peek == '=' {
+		switch peek {
+		case '=':
 			tok = token.NewTokenDuplicated(token.GREATER_EQ, l.ch, l.column, l.line, peek)
 			l.readChar()
-		} else {
+		case '>':
+			tok = token.NewTokenDuplicated(token.SHR, l.ch, l.column, l.line, peek)
+			l.readChar()
+		default:
 			tok = token.NewToken(token.GREATER, l.ch, l.column, l.line)
 		}
 	case '(':
@@ -183,8 +360,19 @@ func (l *Lexer) GetToken() (token.Token, error) {
 	case ')':
 		tok = token.NewToken(token.RPAREN, l.ch, l.column, l.line)
 	case '{':
+		if n := len(l.templateBraces); n > 0 {
+			l.templateBraces[n-1]++
+		}
 		tok = token.NewToken(token.LBRACE, l.ch, l.column, l.line)
 	case '}':
+		if n := len(l.templateBraces); n > 0 {
+			if l.templateBraces[n-1] == 0 {
+				// 插值表达式结束，继续读取模板字符串
+				l.templateBraces = l.templateBraces[:n-1]
+				return l.readTemplate(false)
+			}
+			l.templateBraces[n-1]--
+		}
 		tok = token.NewToken(token.RBRACE, l.ch, l.column, l.line)
 	case '[':
 		tok = token.NewToken(token.LBRACKET, l.ch, l.column, l.line)
@@ -205,41 +393,19 @@ func (l *Lexer) GetToken() (token.Token, error) {
 			l.column = 0
 		} else {
 			// unknown \r token
-			return token.NewToken(token.ILLEGAL, l.ch, l.column, l.line), &verror.LexerVError{
-				Position: verror.Position{
-					Filename: l.filename,
-					Line:     l.line,
-					Column:   l.column,
-				},
-				Message: "the Lexer parse with expected token",
-			}
+			return l.illegal("the Lexer parse with expected token")
 		}
 	case '\n':
 		tok = token.NewToken(token.NEWLINE, l.ch, l.column, l.line)
 		l.line += 1
 		l.column = 0
-	case '"':
-		l.readChar()
-		pos := l.position
-		for l.ch != '"' {
-			l.readChar()
-		}
-		tok.Value = l.input[pos:l.position]
-		tok.Column = l.column
-		tok.Line = l.line
-		tok.Type = token.STRING
+	case '"', '\'':
+		return l.readString()
+	case '`':
+		return l.readTemplate(true)
 	default:
 		if utils.IsDigit(l.ch) {
-			var isFloat bool
-			tok.Value, isFloat = l.readNumber()
-			if isFloat {
-				tok.Type = token.FLOAT
-			} else {
-				tok.Type = token.INT
-			}
-			tok.Column = l.column
-			tok.Line = l.line
-			return tok, nil
+			return l.readNumber()
 		} else if utils.IsIdentifier(l.ch) {
 			tok.Value = l.readIdentifier()
 			switch tok.Value {
@@ -253,40 +419,92 @@ func (l *Lexer) GetToken() (token.Token, error) {
 			tok.Line = l.line
 			return tok, nil
 		}
-		return token.NewToken(token.ILLEGAL, l.ch, l.column, l.line), &verror.LexerVError{
-			Position: verror.Position{
-				Filename: l.filename,
-				Line:     l.line,
-				Column:   l.column,
-			},
-			Message: fmt.Sprintf("the Lexer parse with unexpected token: %q", l.ch),
-		}
+		return l.illegal(fmt.Sprintf("the Lexer parse with unexpected token: %q", l.ch))
 	}
 	l.readChar()
 	return tok, nil
 }
 
+// illegal 生成非法字符 token 并跳过该字符，便于继续扫描
+func (l *Lexer) illegal(msg string) (token.Token, error) {
+	tok := token.NewToken(token.ILLEGAL, l.ch, l.column, l.line)
+	err := l.errorAt(l.line, l.column, msg)
+	l.readChar()
+	return tok, err
+}
+
+// Parse 扫描全部输入，遇到错误时记录并继续扫描
 func (l *Lexer) Parse() {
 	for !l.isEof() {
+		start := l.position
 		tok, err := l.GetToken()
+		tok.Line, tok.Column, tok.Offset = l.posAt(start)
+		tok.End.Line, tok.End.Column, tok.End.Offset = l.posAt(l.position)
 		if err != nil {
-			panic(err)
+			if lexErr, ok := err.(*verror.LexerVError); ok {
+				l.errors = append(l.errors, *lexErr)
+			} else {
+				l.errors = append(l.errors, *l.errorAt(tok.Line, tok.Column, err.Error()))
+			}
+		}
+		if tok.Type == token.ILLEGAL {
+			continue
 		}
 		l.tokens = append(l.tokens, tok)
 	}
 }
 
+// posAt 返回字节偏移 offset 处的行号与列号，offset 需单调递增
+func (l *Lexer) posAt(offset int) (line, column, off int) {
+	for _, ch := range l.input[l.cursor.Offset:offset] {
+		if ch == '\n' {
+			l.cursor.Line++
+			l.cursor.Column = 1
+		} else {
+			l.cursor.Column++
+		}
+	}
+	l.cursor.Offset = offset
+	return l.cursor.Line, l.cursor.Column, offset
+}
+
+// Errors 返回扫描过程中记录的所有词法错误
+func (l *Lexer) Errors() []verror.LexerVError {
+	return l.errors
+}
+
+// Err 将所有词法错误合并为一个 error，没有错误时返回 nil
+func (l *Lexer) Err() error {
+	if len(l.errors) == 0 {
+		return nil
+	}
+	list := make(verror.ErrorList, len(l.errors))
+	for i := range l.errors {
+		list[i] = &l.errors[i]
+	}
+	return list
+}
+
+func (l *Lexer) FileName() string {
+	return l.filename
+}
+
 func (l *Lexer) Tokens() []token.Token {
 	return l.tokens
 }
 
 func (l *Lexer) TheEof() token.Token {
-	var lastTk = l.tokens[len(l.tokens)-1]
+	if len(l.tokens) == 0 {
+		return token.Token{Type: token.EOF, Value: string(token.EOF), Column: l.column, Line: l.line}
+	}
+	var end = l.tokens[len(l.tokens)-1].End
 	return token.Token{
 		Type:   token.EOF,
 		Value:  string(token.EOF),
-		Column: lastTk.Column,
-		Line:   lastTk.Line,
+		Column: end.Column,
+		Line:   end.Line,
+		Offset: end.Offset,
+		End:    end,
 	}
 }
 
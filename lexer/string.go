@@ -0,0 +1,202 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"vine-lang/token"
+)
+
+// readString 读取字符串字面量
+// 支持 "..." 与 '...' 两种引号以及转义序列，
+// 三个连续引号包裹的字符串为可跨行的原始字符串，不处理转义
+func (l *Lexer) readString() (token.Token, error) {
+	quote := l.ch
+	tok := token.Token{Type: token.STRING, Line: l.line, Column: l.column}
+
+	if l.peekRuneAt(1) == quote && l.peekRuneAt(2) == quote {
+		return l.readRawString(tok, quote)
+	}
+
+	l.readChar() // 跳过开头的引号
+	var sb strings.Builder
+	var firstErr error
+	for l.ch != quote {
+		if l.isEof() || l.ch == '\n' {
+			return tok, l.errorAt(tok.Line, tok.Column, "unterminated string literal")
+		}
+		if l.ch == '\\' {
+			// 遇到非法转义时继续扫描到字符串结尾，保证词法分析位置正确
+			if err := l.readEscape(&sb); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sb.WriteRune(l.ch)
+		l.readChar()
+	}
+	l.readChar() // 跳过结尾的引号
+
+	tok.Value = sb.String()
+	return tok, firstErr
+}
+
+// readRawString 读取三引号包裹的多行原始字符串
+func (l *Lexer) readRawString(tok token.Token, quote rune) (token.Token, error) {
+	for range 3 {
+		l.readChar()
+	}
+	start := l.position
+	for {
+		if l.isEof() {
+			return tok, l.errorAt(tok.Line, tok.Column, "unterminated multi-line string literal")
+		}
+		if l.ch == quote && l.peekRuneAt(1) == quote && l.peekRuneAt(2) == quote {
+			break
+		}
+		l.skipStringChar()
+	}
+	tok.Value = l.input[start:l.position]
+	for range 3 {
+		l.readChar()
+	}
+	return tok, nil
+}
+
+// skipStringChar 前进一个字符，并维护字符串内部的换行计数
+func (l *Lexer) skipStringChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.column = 0
+	}
+	l.readChar()
+}
+
+// readEscape 解析以 '\' 开头的转义序列并写入 sb
+// 遇到换行或文件结尾时不消费字符，由调用方报告未闭合错误
+func (l *Lexer) readEscape(sb *strings.Builder) error {
+	line, col := l.line, l.column
+	l.readChar() // 跳过 '\'
+
+	switch l.ch {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case '0':
+		sb.WriteByte(0)
+	case 'b':
+		sb.WriteByte('\b')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'v':
+		sb.WriteByte('\v')
+	case '\\', '"', '\'', '`', '$':
+		sb.WriteRune(l.ch)
+	case 'x':
+		l.readChar()
+		digits := l.readHexDigits(2)
+		if len(digits) != 2 {
+			return l.errorAt(line, col, "invalid escape sequence: \\x requires 2 hex digits")
+		}
+		// \xHH 表示码点 U+00HH，与 \u00HH 相同，保证字符串仍是合法的 UTF-8
+		v, _ := strconv.ParseUint(digits, 16, 8)
+		sb.WriteRune(rune(v))
+		return nil
+	case 'u':
+		l.readChar()
+		var digits string
+		if l.ch == '{' {
+			l.readChar()
+			digits = l.readHexDigits(6)
+			if l.ch != '}' || len(digits) == 0 {
+				return l.errorAt(line, col, "invalid escape sequence: \\u{...} requires 1 to 6 hex digits")
+			}
+			l.readChar()
+		} else {
+			digits = l.readHexDigits(4)
+			if len(digits) != 4 {
+				return l.errorAt(line, col, "invalid escape sequence: \\u requires 4 hex digits")
+			}
+		}
+		v, _ := strconv.ParseUint(digits, 16, 32)
+		if !utf8.ValidRune(rune(v)) {
+			return l.errorAt(line, col, fmt.Sprintf("invalid unicode code point: U+%s", strings.ToUpper(digits)))
+		}
+		sb.WriteRune(rune(v))
+		return nil
+	default:
+		if l.isEof() || l.ch == '\n' {
+			return nil
+		}
+		err := l.errorAt(line, col, fmt.Sprintf("invalid escape sequence: \\%c", l.ch))
+		l.readChar()
+		return err
+	}
+	l.readChar()
+	return nil
+}
+
+// readHexDigits 最多读取 max 个十六进制数字
+func (l *Lexer) readHexDigits(max int) string {
+	start := l.position
+	for n := 0; n < max && isHexDigit(l.ch); n++ {
+		l.readChar()
+	}
+	return l.input[start:l.position]
+}
+
+func isHexDigit(ch rune) bool {
+	return ('0' <= ch && ch <= '9') || ('a' <= ch && ch <= 'f') || ('A' <= ch && ch <= 'F')
+}
+
+// readTemplate 读取模板字符串的一个片段
+// 片段从 '`' 或插值结束的 '}' 开始，到 '`' 或下一个 '${' 结束：
+// 完整的 `...` 为 TEMPLATE，`...${ 为 TEMPLATE_HEAD，}...${ 为 TEMPLATE_MIDDLE，}...` 为 TEMPLATE_TAIL
+func (l *Lexer) readTemplate(isHead bool) (token.Token, error) {
+	tok := token.Token{Line: l.line, Column: l.column}
+	l.readChar() // 跳过 '`' 或 '}'
+
+	var sb strings.Builder
+	var firstErr error
+	for {
+		if l.isEof() {
+			return tok, l.errorAt(tok.Line, tok.Column, "unterminated template literal")
+		}
+		if l.ch == '`' {
+			l.readChar()
+			if isHead {
+				tok.Type = token.TEMPLATE
+			} else {
+				tok.Type = token.TEMPLATE_TAIL
+			}
+			break
+		}
+		if l.ch == '$' && l.peekRune() == '{' {
+			l.readChar()
+			l.readChar()
+			if isHead {
+				tok.Type = token.TEMPLATE_HEAD
+			} else {
+				tok.Type = token.TEMPLATE_MIDDLE
+			}
+			// 进入插值表达式，记录花括号深度
+			l.templateBraces = append(l.templateBraces, 0)
+			break
+		}
+		if l.ch == '\\' {
+			if err := l.readEscape(&sb); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		sb.WriteRune(l.ch)
+		l.skipStringChar()
+	}
+
+	tok.Value = sb.String()
+	return tok, firstErr
+}
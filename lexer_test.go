@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"vine-lang/token"
+)
+
+// TestLexerErrors 测试词法分析遇到错误后继续，并按出现顺序报告全部错误的位置
+func TestLexerErrors(t *testing.T) {
+	code := "let a = 1 @ 2\nlet b = \"x\" $\nlet s = \"open\nlet c = 0b12\nlet d = ` \n"
+	errs, tokens := lexErrors("lexer.vine", code)
+
+	want := []struct {
+		line, column int
+		msg          string
+	}{
+		{1, 11, "the Lexer parse with unexpected token: '@'"},
+		{2, 13, "the Lexer parse with unexpected token: '$'"},
+		{3, 9, "unterminated string literal"},
+		{4, 9, "malformed number literal: 0b12"},
+		{5, 9, "unterminated template literal"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(errs), len(want), errs)
+	}
+	for index, w := range want {
+		e := errs[index]
+		if e.Filename != "lexer.vine" || e.Line != w.line || e.Column != w.column || e.Message != w.msg {
+			t.Errorf("error %d = %s:%d:%d %q, want lexer.vine:%d:%d %q", index, e.Filename, e.Line, e.Column, e.Message, w.line, w.column, w.msg)
+		}
+	}
+
+	// 出错之后的 token 仍然被识别
+	var lets, ints int
+	for _, tk := range tokens {
+		switch tk.Type {
+		case token.LET:
+			lets++
+		case token.INT:
+			ints++
+		}
+	}
+	if lets != 5 || ints != 3 {
+		t.Errorf("got %d let and %d int tokens, want 5 and 3", lets, ints)
+	}
+}
@@ -1,8 +1,14 @@
 package global
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"vine-lang/object/ranges"
 	"vine-lang/object/store"
+	"vine-lang/token"
 	"vine-lang/types"
 	"vine-lang/utils"
 )
@@ -30,6 +36,12 @@ func (g *GlobalModule) Name() string {
 	return "global"
 }
 
+// ObjectStringer 返回对象自定义的字符串形式，没有自定义时返回 false
+// 解释器的环境实现该接口，通过它调用类型的 __str__ 方法
+type ObjectStringer interface {
+	ObjectString(obj *store.StoreObject) (string, bool)
+}
+
 /* FN */
 
 // 打印
@@ -39,29 +51,107 @@ func Print(env any, rangeArgs ...any) {
 	}
 
 	for _, arg := range rangeArgs {
-		if v, ok := arg.(*store.StoreObject); ok {
-			fmt.Print(store.StoreObjectToReadableJSON(v), " ")
+		if s, ok := formatObject(env, arg); ok {
+			fmt.Print(s, " ")
 			continue
 		}
-		if v, ok := arg.(*types.FunctionLikeValNode); ok {
-			fmt.Print(fmt.Sprintf("<fn %p>", v), " ")
-			continue
+		fmt.Print(utils.TrasformPrintStringWithColor(arg), " ")
+	}
+	fmt.Println()
+}
+
+// ToString 按照 print 的规则将值转换为不带颜色的字符串，env 为转换所在的环境
+func ToString(env any, arg any) string {
+	if s, ok := formatObject(env, arg); ok {
+		return s
+	}
+	switch v := arg.(type) {
+	case nil:
+		return "nil"
+	case token.Token:
+		if v.Type == token.NIL {
+			return "nil"
 		}
-		if v, ok := arg.(*types.LibsModuleObject); ok {
-			fmt.Print(fmt.Sprintf("<module %p>", v), " ")
-			continue
+	}
+	return utils.TrasformPrintString(arg)
+}
+
+// formatObject 格式化对象、数组、函数、模块等非基础类型的值
+// 数组与对象中嵌套的值同样调用 __str__、带上类型名
+func formatObject(env any, arg any) (string, bool) {
+	switch v := arg.(type) {
+	case *store.StoreObject:
+		return formatValue(env, v, ""), true
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = ToString(env, item)
 		}
-		if v, ok := arg.(*types.TaskToValNode); ok {
-			fmt.Print(fmt.Sprintf("<task %p>", v), " ")
-			continue
+		return "[" + strings.Join(items, " ") + "]", true
+	case *ranges.RangeObject:
+		return v.String(), true
+	case *types.FunctionLikeValNode:
+		return fmt.Sprintf("<fn %p>", v), true
+	case *types.TypeValNode:
+		return fmt.Sprintf("<type %s>", v.Name), true
+	case *types.LibsModuleObject:
+		return fmt.Sprintf("<module %p>", v), true
+	case *types.TaskToValNode:
+		return fmt.Sprintf("<task %p>", v), true
+	case *types.ErrorValNode:
+		return fmt.Sprintf("<error %p>", v), true
+	}
+	return "", false
+}
+
+// formatValue 以缩进的 JSON 形式格式化对象中的值，indent 为当前行的缩进
+func formatValue(env any, val any, indent string) string {
+	switch v := val.(type) {
+	case *store.StoreObject:
+		if stringer, ok := env.(ObjectStringer); ok {
+			if s, ok := stringer.ObjectString(v); ok {
+				return s
+			}
 		}
-		if v, ok := arg.(*types.ErrorValNode); ok {
-			fmt.Print(fmt.Sprintf("<error %p>", v), " ")
+		// 用户类型的实例以类型名开头，只输出自身的字段
+		if name := v.TypeName(); name != "" {
+			return name + " " + formatFields(env, store.StoreObjectToMap(v), indent)
 		}
+		return formatFields(env, store.StoreObjectToMap(v), indent)
+	case map[string]any:
+		return formatFields(env, v, indent)
+	case []any:
+		if len(v) == 0 {
+			return "[]"
+		}
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = indent + "  " + formatValue(env, item, indent+"  ")
+		}
+		return "[\n" + strings.Join(items, ",\n") + "\n" + indent + "]"
+	}
+	if s, ok := formatObject(env, val); ok {
+		return s
+	}
+	raw, err := json.Marshal(store.ToJSONValue(val))
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(raw)
+}
 
-		fmt.Print(utils.TrasformPrintStringWithColor(arg), " ")
+// formatFields 按键名排序输出对象的字段
+func formatFields(env any, fields map[string]any, indent string) string {
+	if len(fields) == 0 {
+		return "{}"
 	}
-	fmt.Println()
+	keys := slices.Sorted(maps.Keys(fields))
+	items := make([]string, len(keys))
+	for i, k := range keys {
+		key, _ := json.Marshal(k)
+		items[i] = indent + "  " + string(key) + ": " + formatValue(env, fields[k], indent+"  ")
+	}
+	return "{\n" + strings.Join(items, ",\n") + "\n" + indent + "}"
 }
 
 func PrintWithColor(env any, rangeArgs ...any) {
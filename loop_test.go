@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+// TestLoops 测试 while、loop 以及带标签的 break 与 continue
+func TestLoops(t *testing.T) {
+	checkValues(t, "loop.vine", []valueTest{
+		{"let n = 1\nwhile n < 100:\n    n *= 2\nend\nn", int64(128)},
+		{"let c = 0\nloop:\n    c++\n    if c % 2 == 0:\n        continue\n    end\n    if c > 7:\n        break\n    end\nend\nc", int64(9)},
+		{"let found = nil\nouter: for i in 1..9:\n    for j in 1..9:\n        if i * j == 42:\n            found = [i, j]\n            break outer\n        end\n    end\nend\nfound", []any{int64(6), int64(7)}},
+		{"let pairs = []\nrows: for i in 0..<3:\n    let j = 0\n    while true:\n        if j > i:\n            continue rows\n        end\n        pairs = [...pairs, i * 10 + j]\n        j++\n    end\nend\npairs", []any{int64(0), int64(10), int64(11), int64(20), int64(21), int64(22)}},
+		{"let hits = 0\nscan: loop:\n    hits++\n    switch hits:\n        case 3:\n            break scan\n        default:\n            break\n    end\nend\nhits", int64(3)},
+		{"let out = []\nfor i in 0..<5:\n    if i == 1:\n        continue\n    end\n    if i == 3:\n        break\n    end\n    out = [...out, i]\nend\nout", []any{int64(0), int64(2)}},
+	})
+}
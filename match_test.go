@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestMatch 测试值、区间、解构、类型与通配符模式以及守卫
+func TestMatch(t *testing.T) {
+	describe := "fn describe(n):\n    return match n:\n        case 0: \"zero\"\n        case 1, 2, 3: \"small\"\n        case 4..9: \"medium\"\n        case _: \"large\"\n    end\nend\n"
+	head := "fn head(list):\n    return match list:\n        case []: \"empty\"\n        case [only]: only\n        case [first, ...rest]: rest\n    end\nend\n"
+	kind := "fn kind(v):\n    return match v:\n        case typeof int, typeof float: \"number\"\n        case typeof nil: \"nothing\"\n        case _: \"other\"\n    end\nend\n"
+	checkValues(t, "match.vine", []valueTest{
+		{describe + "[describe(0), describe(3), describe(4), describe(9), describe(10)]", []any{"zero", "small", "medium", "medium", "large"}},
+		{"match -5:\n    case x if x < 0: -x\n    case _: 0\nend", int64(5)},
+		{head + "[head([]), head([7]), head([1, 2, 3])]", []any{"empty", int64(7), []any{int64(2), int64(3)}}},
+		{"match {type: \"click\", pos: {x: 1, y: 2}}:\n    case {type: \"key\"}: 0\n    case {type: \"click\", pos: {x, y}}: x + y\nend", int64(3)},
+		{"match {type: \"key\", code: 27}:\n    case {type: \"key\", code: 13}: \"enter\"\n    case {code as c}: c\nend", int64(27)},
+		{kind + "[kind(1), kind(1.5), kind(nil), kind(\"s\")]", []any{"number", "number", "nothing", "other"}},
+		{"match 85:\n    case 90..100: \"A\"\n    case 80..<90:\n        let base = \"B\"\n        base + \"+\"\n    case _: \"C\"\nend", "B+"},
+		{"match [1, 2]:\n    case [1, 2]: \"pair\"\n    case _: \"other\"\nend", "pair"},
+	})
+}
+
+// TestMatchValuePatterns 测试 ^name、限定名与标识符区间按值比较，不绑定新变量
+func TestMatchValuePatterns(t *testing.T) {
+	limit := "let MAX = 10\nfn at(n):\n    return match n:\n        case ^MAX: \"max\"\n        case [^MAX, x]: x\n        case _: \"other\"\n    end\nend\n"
+	between := "let lo = 1\nlet hi = 5\nfn inside(n):\n    return match n:\n        case lo..hi: \"in\"\n        case _: \"out\"\n    end\nend\n"
+	checkValues(t, "match.vine", []valueTest{
+		{limit + "[at(10), at(3), at([10, 2]), at([3, 2])]", []any{"max", "other", int64(2), "other"}},
+		{between + "[inside(3), inside(7)]", []any{"in", "out"}},
+		{"let Color = {RED: 1, BLUE: 2}\nmatch 2:\n    case Color.RED: \"red\"\n    case Color.BLUE: \"blue\"\nend", "blue"},
+	})
+}
+
+// TestMatchUnreachable 测试没有守卫的绑定或通配符分支之后还有分支时报错
+func TestMatchUnreachable(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"let MAX = 10\nmatch 3:\n    case MAX: 1\n    case _: 2\nend\n", "[Line 3, Column 10] Parser Error: pattern MAX binds every value, so later arms are unreachable; use ^MAX to compare with the value of MAX"},
+		{"match 3:\n    case 1, _: 1\n    case 2: 2\nend\n", "[Line 3, Column 5] Parser Error: unreachable match arm: the previous arm matches every value with _"},
+	}
+	for _, tt := range tests {
+		list := parseErrors(t, tt.code)
+		if len(list) != 1 || !strings.Contains(list[0].Error(), tt.want) {
+			t.Errorf("%q: errors = %v, want %s", tt.code, list, tt.want)
+		}
+	}
+}
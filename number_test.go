@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+// TestNumberLiterals 测试不同进制、数字分隔符与指数形式的数字字面量
+func TestNumberLiterals(t *testing.T) {
+	checkValues(t, "number.vine", []valueTest{
+		{"[0xff, 0o17, 0b1010, 0XFF]", []any{int64(255), int64(15), int64(10), int64(255)}},
+		{"[1_000_000, 0xFF_FF, 0b1111_0000]", []any{int64(1000000), int64(65535), int64(240)}},
+		{"[3.14, 1e-9, 2.5e3, 6.02E23, 1_0.5]", []any{3.14, 1e-9, 2500.0, 6.02e23, 10.5}},
+	})
+}
+
+// TestNumberErrors 测试格式错误的数字字面量报告在字面量的起始位置
+func TestNumberErrors(t *testing.T) {
+	tests := []struct {
+		code    string
+		literal string
+		line    int
+		column  int
+	}{
+		{"let a = 1.2.3", "1.2.3", 1, 9},
+		{"let a = 0xZZ", "0xZZ", 1, 9},
+		{"let a = 0b102", "0b102", 1, 9},
+		{"let a = 0o9", "0o9", 1, 9},
+		{"let a = 1__0", "1__0", 1, 9},
+		{"let a = 1_", "1_", 1, 9},
+		{"let a = 0x", "0x", 1, 9},
+		{"let a = 1e", "1e", 1, 9},
+		{"let a = 1\nlet b = [1, 12abc]", "12abc", 2, 13},
+	}
+	for _, tt := range tests {
+		errs, _ := lexErrors("number.vine", tt.code)
+		if len(errs) != 1 {
+			t.Errorf("%q: got %d errors, want 1: %v", tt.code, len(errs), errs)
+			continue
+		}
+		e := errs[0]
+		want := "malformed number literal: " + tt.literal
+		if e.Filename != "number.vine" || e.Line != tt.line || e.Column != tt.column || e.Message != want {
+			t.Errorf("%q: error = %s:%d:%d %q, want number.vine:%d:%d %q", tt.code, e.Filename, e.Line, e.Column, e.Message, tt.line, tt.column, want)
+		}
+	}
+}
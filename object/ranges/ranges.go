@@ -0,0 +1,89 @@
+package ranges
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// RangeObject 整数区间，按需计算元素，不分配数组
+type RangeObject struct {
+	Start     int64
+	End       int64
+	Step      int64
+	Inclusive bool // 是否包含 End
+}
+
+// New 创建区间，未指定 step 时按起止方向取 1 或 -1
+func New(start, end, step int64, inclusive bool, hasStep bool) (*RangeObject, error) {
+	if !hasStep {
+		step = 1
+		if start > end {
+			step = -1
+		}
+	}
+	if step == 0 {
+		return nil, errors.New("range step cannot be zero")
+	}
+	return &RangeObject{Start: start, End: end, Step: step, Inclusive: inclusive}, nil
+}
+
+// Len 返回区间内元素个数，超过 math.MaxInt64 时返回 math.MaxInt64
+// 起止之差按 uint64 计算，避免跨越整个 int64 范围的区间溢出
+func (r *RangeObject) Len() int64 {
+	var span, step uint64
+	if r.Step > 0 {
+		if r.End < r.Start {
+			return 0
+		}
+		span, step = uint64(r.End)-uint64(r.Start), uint64(r.Step)
+	} else {
+		if r.End > r.Start {
+			return 0
+		}
+		span, step = uint64(r.Start)-uint64(r.End), -uint64(r.Step)
+	}
+	if !r.Inclusive {
+		if span == 0 {
+			return 0
+		}
+		span--
+	}
+	if n := span / step; n < math.MaxInt64 {
+		return int64(n) + 1
+	}
+	return math.MaxInt64
+}
+
+// At 返回第 index 个元素，调用方保证 index 在区间内
+func (r *RangeObject) At(index int64) int64 {
+	return r.Start + index*r.Step
+}
+
+// Contains 判断整数是否为区间内的元素
+func (r *RangeObject) Contains(v int64) bool {
+	var offset, step uint64
+	if r.Step > 0 {
+		if v < r.Start || v > r.End || v == r.End && !r.Inclusive {
+			return false
+		}
+		offset, step = uint64(v)-uint64(r.Start), uint64(r.Step)
+	} else {
+		if v > r.Start || v < r.End || v == r.End && !r.Inclusive {
+			return false
+		}
+		offset, step = uint64(r.Start)-uint64(v), -uint64(r.Step)
+	}
+	return offset%step == 0
+}
+
+func (r *RangeObject) String() string {
+	op := ".."
+	if !r.Inclusive {
+		op = "..<"
+	}
+	if r.Step == 1 || (r.Step == -1 && r.Start > r.End) {
+		return fmt.Sprintf("%d%s%d", r.Start, op, r.End)
+	}
+	return fmt.Sprintf("%d%s%d step %d", r.Start, op, r.End, r.Step)
+}
@@ -3,7 +3,9 @@ package store
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"strconv"
 	"vine-lang/token"
 	LibsUtils "vine-lang/utils"
@@ -14,6 +16,8 @@ type StoreObject struct {
 	parent  *StoreObject
 	store   map[string]any
 	nameMap map[string]token.Token
+	keys    []string // 按定义顺序记录的键
+	name    string   // 作为类型原型时的类型名
 }
 
 func NewStoreObject() *StoreObject {
@@ -24,6 +28,21 @@ func NewStoreObject() *StoreObject {
 	}
 }
 
+// NewPrototype 创建类型的原型，parent 为父类型的原型，可能为 nil
+func NewPrototype(name string, parent *StoreObject) *StoreObject {
+	proto := NewStoreObject()
+	proto.name = name
+	proto.parent = parent
+	return proto
+}
+
+// NewInstance 创建以 proto 为原型的实例，找不到的属性沿原型链查找
+func NewInstance(proto *StoreObject) *StoreObject {
+	obj := NewStoreObject()
+	obj.parent = proto
+	return obj
+}
+
 func NewStoreObjectWithGoStruct(val any) *StoreObject {
 	t := reflect.TypeOf(val)
 	v := reflect.ValueOf(val)
@@ -74,6 +93,7 @@ func NewStoreObjectWithGoStruct(val any) *StoreObject {
 		store:   store,
 		nameMap: make(map[string]token.Token),
 		parent:  nil,
+		keys:    slices.Sorted(maps.Keys(store)),
 	}
 
 	// 重建nameMap
@@ -89,7 +109,7 @@ func StoreObjectToMap(e *StoreObject) map[string]any {
 }
 
 func StoreObjectToReadableJSON(e *StoreObject) string {
-	data := toJSONValue(e)
+	data := ToJSONValue(e)
 	raw, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Sprint(data)
@@ -97,7 +117,8 @@ func StoreObjectToReadableJSON(e *StoreObject) string {
 	return string(raw)
 }
 
-func toJSONValue(val any) any {
+// ToJSONValue 将值转换为 encoding/json 可以直接编码的形式
+func ToJSONValue(val any) any {
 	switch v := val.(type) {
 	case nil:
 		return nil
@@ -106,16 +127,13 @@ func toJSONValue(val any) any {
 	case map[string]any:
 		res := make(map[string]any, len(v))
 		for k, item := range v {
-			if k == "__proto__" {
-				continue
-			}
-			res[k] = toJSONValue(item)
+			res[k] = ToJSONValue(item)
 		}
 		return res
 	case []any:
 		res := make([]any, len(v))
 		for i, item := range v {
-			res[i] = toJSONValue(item)
+			res[i] = ToJSONValue(item)
 		}
 		return res
 	case token.Token:
@@ -148,10 +166,7 @@ func toJSONValue(val any) any {
 func storeObjectToJSONMap(e *StoreObject) map[string]any {
 	res := make(map[string]any)
 	for k, v := range e.store {
-		if k == "__proto__" {
-			continue
-		}
-		res[k] = toJSONValue(v)
+		res[k] = ToJSONValue(v)
 	}
 	return res
 }
@@ -198,20 +213,51 @@ func (e *StoreObject) Define(name token.Token, val any) error {
 	} else {
 		e.store[name.Value] = val
 		e.nameMap[name.Value] = name
+		e.keys = append(e.keys, name.Value)
 	}
 	return nil
 }
 
+// Put 设置自身的属性，不存在时新增
+func (e *StoreObject) Put(name token.Token, val any) {
+	if _, exists := e.store[name.Value]; !exists {
+		e.nameMap[name.Value] = name
+		e.keys = append(e.keys, name.Value)
+	}
+	e.store[name.Value] = val
+}
+
 func (e *StoreObject) Print() {
 	for k, v := range e.store {
 		println(k, LibsUtils.TrasformPrintString(v))
 	}
 }
 
+// ForEach 按定义顺序遍历自身的键值
 func (e *StoreObject) ForEach(fn func(tk token.Token, val any)) {
-	for k, v := range e.store {
-		fn(token.Token{Type: token.IDENT, Value: k}, v)
+	for _, k := range e.keys {
+		fn(token.Token{Type: token.IDENT, Value: k}, e.store[k])
+	}
+}
+
+// Keys 按定义顺序返回自身的键
+func (e *StoreObject) Keys() []string {
+	return slices.Clone(e.keys)
+}
+
+// Parent 返回原型链上的下一个对象
+func (e *StoreObject) Parent() *StoreObject {
+	return e.parent
+}
+
+// TypeName 返回对象所属类型的名字，普通对象返回空字符串
+func (e *StoreObject) TypeName() string {
+	for proto := e.parent; proto != nil; proto = proto.parent {
+		if proto.name != "" {
+			return proto.name
+		}
 	}
+	return ""
 }
 
 func (e *StoreObject) IsEmpty() bool {
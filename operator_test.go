@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"vine-lang/env"
+	"vine-lang/libs/global"
+)
+
+const money = `
+type Money:
+    let cents = 0
+
+    fn init(cents):
+        self.cents = cents
+    end
+
+    fn __add__(other):
+        Money(self.cents + (other is Money ? other.cents : other))
+    end
+
+    fn __radd__(other):
+        self + other
+    end
+
+    fn __sub__(other):
+        Money(self.cents - other.cents)
+    end
+
+    fn __mul__(k):
+        Money(self.cents * k)
+    end
+
+    fn __neg__():
+        Money(-self.cents)
+    end
+
+    fn __invert__():
+        "inverted"
+    end
+
+    fn __eq__(other):
+        other is Money and self.cents == other.cents
+    end
+
+    fn __lt__(other):
+        self.cents < other.cents
+    end
+
+    fn __le__(other):
+        self.cents <= other.cents
+    end
+
+    fn __str__():
+        ` + "`${self.cents}c`" + `
+    end
+end
+`
+
+// TestOperatorMethods 测试运算符分派到用户类型的特殊方法
+func TestOperatorMethods(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"(Money(1) + Money(2)).cents", int64(3)},
+		{"(Money(5) + 1).cents", int64(6)},
+		{"(1 + Money(5)).cents", int64(6)},
+		{"(Money(5) - Money(2)).cents", int64(3)},
+		{"(Money(5) * 3).cents", int64(15)},
+		{"(-Money(5)).cents", int64(-5)},
+		{"~Money(5)", "inverted"},
+		{"let m = Money(1)\nm += Money(2)\nm += 3\nm.cents", int64(6)},
+		{"Money(1) == Money(1)", true},
+		{"Money(1) == 1", false},
+		{"Money(1) != Money(2)", true},
+		{"Money(1) != Money(1)", false},
+		{"Money(1) < Money(2)", true},
+		{"Money(1) <= Money(1)", true},
+		{"Money(2) > Money(1)", true},
+		{"Money(1) >= Money(2)", false},
+		{"`total: ${Money(1) + Money(2)}`", "total: 3c"},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "operator.vine", money+tt.code, nil); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+
+	if s := global.ToString(nil, evalWith(t, "operator.vine", money+"Money(42)", nil)); strings.HasPrefix(s, "42c") {
+		t.Errorf("print without environment = %q, want the default form", s)
+	}
+}
+
+// TestOperatorStrNested 测试数组与对象中嵌套的对象同样调用 __str__、带上类型名
+func TestOperatorStrNested(t *testing.T) {
+	checkValues(t, "operator.vine", []valueTest{
+		{money + "`${[Money(1), [Money(2)], nil]}`", "[1c [2c] nil]"},
+		{money + "let o = {k: Money(3), l: [Money(4)]}\n`${o}`", "{\n  \"k\": 3c,\n  \"l\": [\n    4c\n  ]\n}"},
+		{"type P:\n    let n = 1\nend\n`${[P()]}`", "[P {\n  \"n\": 1\n}]"},
+		{"type P:\n    let n = 1\nend\nlet o = {p: P(), e: {}}\n`${o}`", "{\n  \"e\": {},\n  \"p\": P {\n    \"n\": 1\n  }\n}"},
+	})
+}
+
+// TestOperatorStrModule 测试导入模块后 __str__ 仍在调用处的环境中执行
+func TestOperatorStrModule(t *testing.T) {
+	dir := t.TempDir()
+	module := "type M:\n    let n = \"m\"\nend\nexpose TAG = \"m\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "mod.vine"), []byte(module), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	code := `use glb pick print
+use "./mod.vine" as m
+let prefix = "P:"
+type P:
+    let n = "a"
+    fn __str__():
+        prefix + self.n
+    end
+end
+print(P(), [P()])
+` + "`${P()} ${m.TAG}`"
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	res, err := executeCode("main.vine", code, env.Workspace{Root: dir, BasePath: dir})
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != "P:a m" {
+		t.Errorf("template = %v, want \"P:a m\"", res)
+	}
+	if got := strings.TrimSpace(string(out)); got != "P:a [P:a]" {
+		t.Errorf("print = %q, want \"P:a [P:a]\"", got)
+	}
+}
+
+// TestOperatorMethodErrors 测试没有对应特殊方法时的错误
+func TestOperatorMethodErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"Money(1) / 2\n", "unsupported operand types for /: Money and int, define __div__"},
+		{"2 - Money(1)\n", "unsupported operand types for -: int and Money"},
+		{"-{a: 1}\n", "invalid operation: - (non-numeric type"},
+		{"type A:\n    fn __str__(): 1 end\nend\n`${A()}`\n", "__str__ must return a string, got int"},
+	}
+	for _, tt := range tests {
+		err := runForError("operator.vine", money+tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
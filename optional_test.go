@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+// TestOptionalChaining 测试 ?. 遇到 nil 时整条链为 nil，?? 只替换 nil
+func TestOptionalChaining(t *testing.T) {
+	checkValues(t, "optional.vine", []valueTest{
+		{"let c = {db: {port: 5432}}\n[c?.db?.port, c?.cache?.port, c.db?.host?.name]", []any{int64(5432), nil, nil}},
+		{"let c = nil\n[c?.a.b.c, c?.[0], c?.f()]", []any{nil, nil, nil}},
+		{"let f = nil\nf?.(1) ?? \"none\"", "none"},
+		{"[0 ?? 1, false ?? 1, \"\" ?? 1, nil ?? 1]", []any{int64(0), false, "", int64(1)}},
+		{"nil ?? nil ?? \"last\"", "last"},
+	})
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+// TestParameters 测试默认参数、剩余参数与具名参数
+func TestParameters(t *testing.T) {
+	checkValues(t, "params.vine", []valueTest{
+		{"fn greet(name, greeting = \"Hello\"): `${greeting}, ${name}!` end\n[greet(\"vine\"), greet(\"vine\", \"Hi\")]", []any{"Hello, vine!", "Hi, vine!"}},
+		{"fn area(w, h = w): w * h end\n[area(3), area(3, 4)]", []any{int64(9), int64(12)}},
+		{"fn f(first, ...rest): [first, rest] end\n[f(1), f(1, 2, 3)]", []any{[]any{int64(1), []any{}}, []any{int64(1), []any{int64(2), int64(3)}}}},
+		{"fn box(w = 1, h = 1, d = 1): [w, h, d] end\n[box(d: 5), box(2, d: 3, h: 4)]", []any{[]any{int64(1), int64(1), int64(5)}, []any{int64(2), int64(4), int64(3)}}},
+		{"fn point({x, y} = {x: 0, y: 0}): x + y end\n[point(), point({x: 1, y: 2})]", []any{int64(0), int64(3)}},
+		{"fn f(a = []): a = [...a, 1]\na end\nf()\nf()", []any{int64(1)}},
+	})
+}
@@ -1,30 +1,57 @@
 package parser
 
 import (
-	"fmt"
 	"slices"
 	"vine-lang/ast"
 	"vine-lang/lexer"
 	"vine-lang/token"
-	"vine-lang/verror"
 )
 
 func CreateParser(lex *lexer.Lexer) *Parser {
 	c := New(lex)
 
-	c.RegisterStmtHandler(token.COMMENT, func(p *Parser) any {
-		return &ast.CommentStmt{
-			Value: p.advance(),
+	c.RegisterStmtHandlerWithKeyWords([]token.TokenType{token.COMMENT, token.BLOCK_COMMENT}, func(p *Parser) any {
+		return p.parseComment()
+	})
+
+	c.RegisterStmtHandler(token.DOC_COMMENT, func(p *Parser) any {
+		// 向后查找连续的文档注释（之间只隔一个换行）
+		var size = 1
+		for p.peekIndex(size).Type == token.NEWLINE && p.peekIndex(size+1).Type == token.DOC_COMMENT {
+			size += 2
 		}
+		// 文档注释后紧跟可注释的声明时，附加到该声明上
+		if p.peekIndex(size).Type == token.NEWLINE && slices.Contains(docTargets, p.peekIndex(size+1).Type) {
+			var list []token.Token
+			for range size + 1 {
+				if tk := p.advance(); tk.Type == token.DOC_COMMENT {
+					list = append(list, tk)
+				}
+			}
+			stmt := p.parseStatement()
+			attachDoc(stmt, ast.NewCommentGroup(list))
+			return stmt
+		}
+		// 否则作为普通注释处理
+		return p.parseComment()
 	})
 
 	c.RegisterStmtHandlerWithKeyWords([]token.TokenType{token.LET, token.CST}, func(p *Parser) any {
 		startToken := p.advance()
 		isConst := startToken.Type == token.CST
 
+		// 解构声明 let [a, b] = ... / let {a, b} = ...
+		if p.peek().Type == token.LBRACKET || p.peek().Type == token.LBRACE {
+			pattern := p.parsePattern()
+			p.expect(token.ASSIGN)
+			decl := ast.NewVariableDecl(ast.Literal{}, p.parseExpression(), isConst)
+			decl.Pattern = pattern
+			return decl
+		}
+
 		idTk := p.expect(token.IDENT)
 
-		id := ast.NewLiteral(&idTk)
+		id := p.createLiteral(idTk)
 		p.expect(token.ASSIGN)
 
 		value := p.parseExpression()
@@ -33,11 +60,11 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 	})
 
 	c.RegisterStmtHandler(token.USE, func(p *Parser) any {
-		p.advance() // skip 'use'
+		startTk := p.advance() // skip 'use'
 		var source *ast.Literal
 		likeSource := p.parsePrimaryExpression()
 		if _, e := likeSource.(*ast.Literal); !e {
-			panic(fmt.Sprintf("expected literal, got %s", likeSource.String()))
+			p.errorf(startTk, "expected literal, got %s", likeSource.String())
 		}
 		source = likeSource.(*ast.Literal)
 		var specifiers []ast.Specifier
@@ -61,12 +88,12 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 							if al, ok := aliasExpr.(*ast.Literal); ok {
 								aliasLit = al
 							} else {
-								panic(fmt.Sprintf("expected alias literal, got %s", aliasExpr.String()))
+								p.errorf(startTk, "expected alias literal, got %s", aliasExpr.String())
 							}
 						}
 						specifiers = append(specifiers, ast.NewUseSpecifier(lit, aliasLit))
 					} else {
-						panic(fmt.Sprintf("expected literal, got %s", remoteExpr.String()))
+						p.errorf(startTk, "expected literal, got %s", remoteExpr.String())
 					}
 					if p.peek().Type == token.COMMA {
 						p.advance()
@@ -84,12 +111,12 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 						if al, ok := aliasExpr.(*ast.Literal); ok {
 							aliasLit = al
 						} else {
-							panic(fmt.Sprintf("expected alias literal, got %s", aliasExpr.String()))
+							p.errorf(startTk, "expected alias literal, got %s", aliasExpr.String())
 						}
 					}
 					specifiers = append(specifiers, ast.NewUseSpecifier(lit, aliasLit))
 				} else {
-					panic(fmt.Sprintf("expected literal, got %s", remoteExpr.String()))
+					p.errorf(startTk, "expected literal, got %s", remoteExpr.String())
 				}
 				return ast.NewUseDecl(source, specifiers, token.PICK)
 			}
@@ -101,35 +128,56 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 	c.RegisterStmtHandler(token.IF, func(p *Parser) any {
 		p.advance() // skip 'if'
 		condition := p.parseExpression()
-		p.expect(token.COLON)
+		colon := p.expect(token.COLON)
 		var body []ast.Stmt
 		for !p.isEof() && !slices.Contains([]token.TokenType{token.END, token.ELSE}, p.peek().Type) {
-			stmt := p.parseStatement()
+			stmt := p.parseStatementSync()
 			if stmt != nil {
 				body = append(body, stmt)
 			}
 		}
+		consequent := ast.NewBlockStmt(body)
+		p.finish(consequent, colon.Pos())
 		if p.peek().Type == token.ELSE {
 			p.advance() // skip 'else'
 			if p.peek().Type == token.IF {
-				return ast.NewIfStmt(condition, ast.NewBlockStmt(body), p.CallStmtHandler(token.IF))
+				return ast.NewIfStmt(condition, consequent, p.CallStmtHandler(token.IF))
 			}
-			return ast.NewIfStmt(condition, ast.NewBlockStmt(body), p.parseBlockStatement())
+			return ast.NewIfStmt(condition, consequent, p.parseBlockStatement())
 		} else {
 			p.expect(token.END)
 		}
-		return ast.NewIfStmt(condition, ast.NewBlockStmt(body), nil)
+		return ast.NewIfStmt(condition, consequent, nil)
 	})
 
 	c.RegisterStmtHandler(token.FOR, func(p *Parser) any {
 		p.advance() // skip 'for'
 		var firstExpr ast.Expr
 
-		if p.peek().Type == token.LET {
+		p.noIn = true
+		switch p.peek().Type {
+		case token.LET:
 			firstExpr = p.parseStatement()
-		} else {
+		case token.LBRACKET, token.LBRACE:
+			firstExpr = p.parsePattern()
+		default:
 			firstExpr = p.parseExpression()
 		}
+		p.noIn = false
+
+		// for key, value in xxx
+		var key ast.Expr
+		if p.peek().Type == token.COMMA {
+			p.advance()
+			if lit, ok := firstExpr.(*ast.Literal); !ok || lit.Value.Type != token.IDENT {
+				p.errorf(p.peek(), "expected identifier before ',' in for loop")
+			}
+			key = firstExpr
+			firstExpr = p.parseBindingTarget()
+			if p.peek().Type != token.IN {
+				p.errorf(p.peek(), "expected 'in' after for loop variables, got %s", p.peek().Type)
+			}
+		}
 
 		var body *ast.BlockStmt
 		// for i in xxx
@@ -137,11 +185,13 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 			p.advance() // skip 'in'
 			iter := p.parseExpression()
 			body = p.parseBlockStatement()
-			return ast.NewForStmt(firstExpr, nil, nil, iter, *body)
+			forStmt := ast.NewForStmt(firstExpr, nil, nil, iter, *body)
+			forStmt.Key = key
+			return forStmt
 		}
 		// for i := 0; i < 10; i++ :
 		p.expect(token.SEMICOLON)
-		secondExpr := p.parseCompareExpression()
+		secondExpr := p.parseExpression()
 		p.expect(token.SEMICOLON)
 		thirdExpr := p.parseExpression()
 		body = p.parseBlockStatement()
@@ -151,12 +201,7 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 	c.RegisterStmtHandler(token.FN, func(p *Parser) any {
 		p.advance() // skip 'fn'
 		id := p.expect(token.IDENT)
-		var args = ast.NewArgsExpr([]ast.Expr{})
-		if p.peek().Type == token.LPAREN {
-			p.expect(token.LPAREN)
-			args = p.parseArgs()
-			p.expect(token.RPAREN)
-		}
+		args := p.parseParamList()
 		return ast.NewFunctionDecl(p.createLiteral(id), args, p.parseBlockStatement())
 	})
 
@@ -167,7 +212,7 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 		case token.FN:
 			decl := p.CallStmtHandler(token.FN)
 			return ast.NewExposeStmt(decl, nil, nil)
-		case token.LET, token.CST:
+		case token.LET, token.CST, token.TYPE:
 			decl := p.CallStmtHandler(p.peek().Type)
 			return ast.NewExposeStmt(decl, nil, nil)
 		case token.IDENT:
@@ -192,9 +237,10 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 		var isDefinedDefault bool = false
 		var cases []ast.Expr
 		for !p.isEof() && p.peek().Type != token.END {
+			caseTk := p.peek()
 			var expr = p.parseSwitchCase()
 			if isDefinedDefault {
-				panic("default case already defined")
+				p.errorf(caseTk, "default case already defined")
 			}
 			if expr != nil {
 				cases = append(cases, expr)
@@ -214,16 +260,11 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 
 	c.RegisterStmtHandler(token.TASK, func(p *Parser) any {
 		p.advance() // skip 'task'
-		fn := p.parseStatement()
-		if fn != nil {
-			if f, ok := fn.(*ast.FunctionDecl); ok {
-				return ast.NewTaskStmt(*f)
-			}
+		if p.peek().Type != token.FN {
+			p.errorf(p.peek(), "expected function declaration after task, got %s", p.peek().Type)
 		}
-		panic(verror.ParseVError{
-			Position: p.peek().ToPosition(""),
-			Message:  "expected function declaration",
-		})
+		fn := p.parseStatement().(*ast.FunctionDecl)
+		return ast.NewTaskStmt(*fn)
 	})
 
 	c.RegisterStmtHandler(token.WAIT, func(p *Parser) any {
@@ -233,13 +274,101 @@ func CreateParser(lex *lexer.Lexer) *Parser {
 
 	c.RegisterStmtHandler(token.BREAK, func(p *Parser) any {
 		p.advance() // skip 'break'
-		return ast.NewBreakStmt()
+		return ast.NewBreakStmt(p.parseLoopLabel())
 	})
 
 	c.RegisterStmtHandler(token.CONTINUE, func(p *Parser) any {
 		p.advance() // skip 'continue'
-		return ast.NewContinueStmt()
+		return ast.NewContinueStmt(p.parseLoopLabel())
+	})
+
+	c.RegisterStmtHandler(token.WHILE, func(p *Parser) any {
+		p.advance() // skip 'while'
+		test := p.parseExpression()
+		return ast.NewWhileStmt(test, *p.parseBlockStatement())
 	})
 
+	c.RegisterStmtHandler(token.LOOP, func(p *Parser) any {
+		p.advance() // skip 'loop'
+		return ast.NewWhileStmt(nil, *p.parseBlockStatement())
+	})
+
+	c.RegisterStmtHandler(token.TYPE, func(p *Parser) any {
+		p.advance() // skip 'type'
+		id := p.createLiteral(p.expect(token.IDENT))
+		// 父类型 type Name(Parent):
+		var parent ast.Expr
+		if p.peek().Type == token.LPAREN {
+			p.advance()
+			parent = p.parseExpression()
+			p.expect(token.RPAREN)
+		}
+		colon := p.expect(token.COLON)
+		var body []ast.Stmt
+		for !p.isEof() && p.peek().Type != token.END {
+			if !slices.Contains(typeMembers, p.peek().Type) {
+				p.errorf(p.peek(), "unexpected %s in type body, expected field or method", p.peek().Value)
+			}
+			stmt := p.parseStatementSync()
+			if stmt != nil {
+				body = append(body, stmt)
+			}
+		}
+		p.expect(token.END)
+		block := ast.NewBlockStmt(body)
+		p.finish(block, colon.Pos())
+		return ast.NewTypeDecl(id, parent, block)
+	})
+
+	// 循环标签 outer: for ...
+	c.RegisterStmtHandler(token.IDENT, func(p *Parser) any {
+		if p.peekIndex(1).Type != token.COLON || !slices.Contains(loopKeywords, p.peekIndex(2).Type) {
+			return nil
+		}
+		label := p.createLiteral(p.advance())
+		p.advance() // skip ':'
+		switch loop := p.parseStatement().(type) {
+		case *ast.ForStmt:
+			loop.Label = label
+			return loop
+		case *ast.WhileStmt:
+			loop.Label = label
+			return loop
+		}
+		return nil
+	})
+
+	for kw, fns := range extensions {
+		for _, fn := range fns {
+			c.RegisterStmtHandler(kw, fn)
+		}
+	}
+
 	return c
 }
+
+// 可以带标签的循环
+var loopKeywords = []token.TokenType{token.FOR, token.WHILE, token.LOOP}
+
+// 类型体中允许出现的语句：字段、方法与注释
+var typeMembers = []token.TokenType{
+	token.LET, token.FN, token.TASK, token.COMMENT, token.BLOCK_COMMENT, token.DOC_COMMENT, token.NEWLINE, token.SEMICOLON,
+}
+
+// 可以附加文档注释的声明
+var docTargets = []token.TokenType{token.FN, token.LET, token.CST, token.TASK, token.EXPOSE, token.TYPE}
+
+func attachDoc(stmt ast.Stmt, doc *ast.CommentGroup) {
+	switch n := stmt.(type) {
+	case *ast.FunctionDecl:
+		n.Doc = doc
+	case *ast.VariableDecl:
+		n.Doc = doc
+	case *ast.TaskStmt:
+		n.Doc = doc
+	case *ast.ExposeStmt:
+		n.Doc = doc
+	case *ast.TypeDecl:
+		n.Doc = doc
+	}
+}
@@ -18,15 +18,28 @@ type Parser struct {
 	errors   []verror.ParseVError // 收集所有错误
 	ast      *ast.ProgramStmt
 	handlers map[token.TokenType][]func(p *Parser) any
+	noIn     bool // 解析 for ... in 的循环变量时为 true，此时 in 不作为运算符
 }
 
 func New(lex *lexer.Lexer) *Parser {
 	p := &Parser{lexer: lex, tokens: []Token{}, position: 0, errors: []verror.ParseVError{}, handlers: make(map[token.TokenType][]func(p *Parser) any)}
 	// 移除不进行解析的token
+	// 行首且不在括号内的块注释作为注释语句保留，其余块注释出现在表达式中间，直接跳过
+	depth := 0
 	for _, tk := range lex.Tokens() {
 		if slices.Contains([]token.TokenType{token.WHITESPACE}, tk.Type) {
 			continue
 		}
+		switch tk.Type {
+		case token.LPAREN, token.LBRACKET, token.LBRACE:
+			depth++
+		case token.RPAREN, token.RBRACKET, token.RBRACE:
+			depth = max(depth-1, 0)
+		case token.BLOCK_COMMENT:
+			if depth > 0 || len(p.tokens) > 0 && p.tokens[len(p.tokens)-1].Type != token.NEWLINE {
+				continue
+			}
+		}
 		p.tokens = append(p.tokens, tk)
 	}
 	return p
@@ -51,7 +64,54 @@ func (p *Parser) RegisterStmtHandlerWithKeyWords(kw []token.TokenType, fns func(
 	}
 }
 
-func (p *Parser) CallStmtHandler(tk token.TokenType) ast.Stmt {
+// 通过 RegisterSyntax 注册的全局语句处理器
+var extensions = map[token.TokenType][]func(p *Parser) any{}
+
+// RegisterSyntax 注册一个全局语句处理器，之后由 CreateParser 创建的解析器都会包含它
+// 处理器返回 ast.Stmt 表示解析成功，返回 nil 则交给下一个处理器；应在初始化阶段调用
+func RegisterSyntax(kw token.TokenType, fn func(p *Parser) any) {
+	extensions[kw] = append(extensions[kw], fn)
+}
+
+// 供扩展语法的处理器使用的解析方法
+
+// Peek 返回当前 token
+func (p *Parser) Peek() Token {
+	return p.peek()
+}
+
+// Advance 消费并返回当前 token
+func (p *Parser) Advance() Token {
+	return p.advance()
+}
+
+// Expect 消费一个指定类型的 token，类型不符时报告错误并中断当前语句
+func (p *Parser) Expect(types ...token.TokenType) Token {
+	return p.expect(types...)
+}
+
+// ParseExpression 解析一个表达式
+func (p *Parser) ParseExpression() ast.Expr {
+	return p.parseExpression()
+}
+
+// ParseBlock 解析 ': ... end' 形式的语句块
+func (p *Parser) ParseBlock() *ast.BlockStmt {
+	return p.parseBlockStatement()
+}
+
+// Errorf 报告一个解析错误并中断当前语句
+func (p *Parser) Errorf(tk Token, format string, args ...any) {
+	p.errorf(tk, format, args...)
+}
+
+func (p *Parser) CallStmtHandler(tk token.TokenType) (stmt ast.Stmt) {
+	start := p.peek().Pos()
+	defer func() {
+		if stmt != nil {
+			p.finish(stmt, start)
+		}
+	}()
 	if handlers, ok := p.handlers[tk]; ok {
 		for _, handler := range handlers {
 			return handler(p).(ast.Stmt)
@@ -77,7 +137,7 @@ func (p *Parser) peekTokenIs(t token.TokenType) bool {
 }
 
 func (p *Parser) peekIndex(index int) Token {
-	if p.isEof() {
+	if p.position+index >= len(p.tokens) {
 		return p.lexer.TheEof()
 	}
 	return p.tokens[p.position+index]
@@ -103,16 +163,138 @@ func (p *Parser) isEof() bool {
 	return p.position >= len(p.tokens)
 }
 
-func (p *Parser) errorf(token Token, format string, args ...any) {
+// bailout 用于在出错时中断当前语句的解析，由 parseStatement 恢复
+type bailout struct{}
+
+// errorf 记录一个解析错误并中断当前语句
+func (p *Parser) errorf(tk Token, format string, args ...any) {
 	msg := fmt.Sprintf(format, args...)
-	err := verror.ParseVError{
-		Position: verror.Position{
-			Line:   token.Line,
-			Column: token.Column},
-		Message: msg,
+	p.errors = append(p.errors, verror.ParseVError{
+		Position: tk.ToPosition(p.lexer.FileName()),
+		Message:  msg,
+	})
+	panic(bailout{})
+}
+
+// Err 将所有解析错误合并为一个 error，没有错误时返回 nil
+func (p *Parser) Err() error {
+	if len(p.errors) == 0 {
+		return nil
+	}
+	list := make(verror.ErrorList, len(p.errors))
+	for i := range p.errors {
+		list[i] = &p.errors[i]
+	}
+	return list
+}
+
+// 出错后同步到这些关键字处，交给外层结构继续解析
+var syncKeywords = []token.TokenType{
+	token.LET, token.CST, token.FN, token.IF, token.ELSE, token.FOR, token.RETURN, token.USE,
+	token.TASK, token.EXPOSE, token.SWITCH, token.CASE, token.DEFAULT, token.BREAK, token.CONTINUE,
+	token.WAIT, token.END, token.WHILE, token.LOOP, token.TYPE,
+}
+
+// synchronize 跳过出错语句剩余的 token，停在下一条语句的开始处
+// 出错的语句打开了代码块时跳到与之匹配的 end 之后，避免块内语句与 end 被当作外层的语句
+func (p *Parser) synchronize(start int) {
+	// 保证至少前进一个 token，避免死循环
+	if p.position == start {
+		p.advance()
+	}
+	depth := 0
+	for index := start; index < p.position; index++ {
+		if p.opensBlock(index) {
+			depth++
+		} else if p.tokens[index].Type == token.END {
+			depth--
+		}
+	}
+	if depth > 0 {
+		for !p.isEof() && depth > 0 {
+			if p.opensBlock(p.position) {
+				depth++
+			} else if p.peek().Type == token.END {
+				depth--
+			}
+			p.advance()
+		}
+		return
+	}
+	for !p.isEof() {
+		tk := p.peek()
+		if tk.Type == token.NEWLINE || tk.Type == token.SEMICOLON {
+			p.advance()
+			return
+		}
+		if slices.Contains(syncKeywords, tk.Type) {
+			return
+		}
+		p.advance()
+	}
+}
+
+// unclosedBracket 查找出错语句中位于行首、却处在未闭合括号内的语句关键字
+// 找到时返回该括号与关键字的下标，关键字所在的代码块（如匿名函数体）内的语句不受影响
+func (p *Parser) unclosedBracket(start int) (Token, int, bool) {
+	var stack []int
+	for index := start; index <= p.position && index < len(p.tokens); index++ {
+		tk := p.tokens[index]
+		if len(stack) > 0 && index > start && p.startsStatement(index) {
+			if top := stack[len(stack)-1]; !p.opensBlock(top) {
+				open := p.tokens[top]
+				return open, index, true
+			}
+		}
+		switch {
+		case tk.Type == token.LPAREN || tk.Type == token.LBRACKET || tk.Type == token.LBRACE || p.opensBlock(index):
+			stack = append(stack, index)
+		case tk.Type == token.RPAREN || tk.Type == token.RBRACKET || tk.Type == token.RBRACE || tk.Type == token.END:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
 	}
+	return Token{}, 0, false
+}
+
+// startsStatement 判断下标处的 token 是否是位于行首、不能出现在表达式中的语句关键字
+// 表达式中的 wait 与匿名函数 fn(...) 除外
+func (p *Parser) startsStatement(index int) bool {
+	tk := p.tokens[index]
+	if !slices.Contains(syncKeywords, tk.Type) || tk.Type == token.WAIT {
+		return false
+	}
+	if tk.Type == token.FN && (index+1 >= len(p.tokens) || p.tokens[index+1].Type != token.IDENT) {
+		return false
+	}
+	prev := index - 1
+	for prev >= 0 && p.tokens[prev].IsComment() {
+		prev--
+	}
+	return prev >= 0 && (p.tokens[prev].Type == token.NEWLINE || p.tokens[prev].Line < tk.Line)
+}
 
-	panic(err)
+// 以 end 结束的结构的起始关键字
+var blockKeywords = []token.TokenType{
+	token.FN, token.IF, token.FOR, token.WHILE, token.LOOP, token.TYPE, token.SWITCH, token.MATCH,
+}
+
+// opensBlock 判断下标处的 token 是否开始一个以 end 结束的结构
+// else if 与 if 共用一个 end，任务调用的 to 链只有紧跟调用的第一个 to 开始新的结构
+func (p *Parser) opensBlock(index int) bool {
+	tk := p.tokens[index]
+	prev := index - 1
+	for prev >= 0 && (p.tokens[prev].Type == token.NEWLINE || p.tokens[prev].IsComment()) {
+		prev--
+	}
+	switch {
+	case tk.Type == token.IF:
+		return prev < 0 || p.tokens[prev].Type != token.ELSE
+	case tk.Type == token.TO:
+		return prev >= 0 && p.tokens[prev].Type == token.RPAREN
+	}
+	return slices.Contains(blockKeywords, tk.Type)
 }
 
 func (p *Parser) expect(types ...token.TokenType) Token {
@@ -121,17 +303,16 @@ func (p *Parser) expect(types ...token.TokenType) Token {
 		p.errorf(current, "Internal error: expect() called with no arguments")
 	}
 
-	var i = 0
-	for !p.isEof() {
-		current := p.peekIndex(i)
-		if slices.Contains(types, current.Type) {
-			return p.advanceIndex(i)
-		} else {
-			i++
-		}
+	// 允许期望的 token 出现在换行或注释之后
+	for !slices.Contains(types, token.NEWLINE) && (p.peek().Type == token.NEWLINE || p.peek().IsComment()) {
+		p.advance()
 	}
 
 	current := p.peek()
+	if slices.Contains(types, current.Type) {
+		return p.advance()
+	}
+
 	typeStr := fmt.Sprintf("%v", types)
 	p.errorf(current, "expected next token to be %s, got %s instead", typeStr, current.Type)
 	return Token{}
@@ -139,10 +320,74 @@ func (p *Parser) expect(types ...token.TokenType) Token {
 
 /* Creaters  */
 func (p *Parser) createLiteral(val token.Token) *ast.Literal {
-	return ast.NewLiteral(&val)
+	lit := ast.NewLiteral(&val)
+	if val.End != (token.Pos{}) {
+		lit.Span = ast.SpanOf(p.lexer.FileName(), val)
+	}
+	return lit
+}
+
+// lastEnd 返回最后一个已消费 token 的结束位置，跳过换行与注释
+func (p *Parser) lastEnd() token.Pos {
+	for i := min(p.position, len(p.tokens)) - 1; i >= 0; i-- {
+		if tk := p.tokens[i]; tk.Type != token.NEWLINE && !tk.IsComment() {
+			return tk.End
+		}
+	}
+	return token.Pos{}
+}
+
+// startPos 返回下一个有效 token 的起始位置，跳过换行与注释
+func (p *Parser) startPos() token.Pos {
+	for i := p.position; i < len(p.tokens); i++ {
+		if tk := p.tokens[i]; tk.Type != token.NEWLINE && !tk.IsComment() {
+			return tk.Pos()
+		}
+	}
+	return p.lexer.TheEof().Pos()
+}
+
+// finish 将节点的范围设置为从 start 到最后一个已消费的 token，已有范围的节点保持不变
+func (p *Parser) finish(node ast.Node, start token.Pos) {
+	n, ok := node.(interface{ SetSpan(ast.Span) })
+	if !ok || !node.NodeSpan().IsZero() {
+		return
+	}
+	end := p.lastEnd()
+	if end.Offset < start.Offset {
+		end = start
+	}
+	n.SetSpan(ast.Span{File: p.lexer.FileName(), Start: start, End: end})
+}
+
+// fillSpans 为解析时没有直接记录范围的节点补全范围，取其所有子节点范围的并集
+func (p *Parser) fillSpans(node ast.Node) ast.Span {
+	var union ast.Span
+	for _, child := range ast.Children(node) {
+		span := p.fillSpans(child)
+		if span.IsZero() {
+			continue
+		}
+		if union.IsZero() || span.Start.Offset < union.Start.Offset {
+			union.Start = span.Start
+		}
+		if union.IsZero() || span.End.Offset > union.End.Offset {
+			union.End = span.End
+		}
+		union.File = span.File
+	}
+	if node.NodeSpan().IsZero() && !union.IsZero() {
+		if n, ok := node.(interface{ SetSpan(ast.Span) }); ok {
+			n.SetSpan(union)
+		}
+	}
+	return node.NodeSpan()
 }
 
 /* Parsers */
+
+// ParseProgram 解析整个程序
+// 出错时不会中断，而是跳过出错的语句继续解析，返回部分 AST，错误通过 GetErrors 或 Err 获取
 func (p *Parser) ParseProgram() *ast.ProgramStmt {
 	p.ast = ast.NewProgramStmt([]ast.Stmt{})
 
@@ -150,16 +395,57 @@ func (p *Parser) ParseProgram() *ast.ProgramStmt {
 		if p.peek().Type == token.EOF {
 			break
 		}
-		if stmt := p.parseStatement(); stmt != nil {
+		if stmt := p.parseStatementSync(); stmt != nil {
 			p.ast.Body = append(p.ast.Body, stmt)
 		}
 	}
 
+	// 程序的范围包含末尾的注释
+	var end token.Pos
+	for i := len(p.tokens) - 1; i >= 0; i-- {
+		if tk := p.tokens[i]; tk.Type != token.NEWLINE && tk.Type != token.EOF {
+			end = tk.End
+			break
+		}
+	}
+	p.ast.Span = ast.Span{File: p.lexer.FileName(), Start: token.Pos{Line: 1, Column: 1}, End: end}
+	p.fillSpans(p.ast)
 	return p.ast
 }
 
-func (p *Parser) parseStatement() ast.Stmt {
+// parseStatementSync 解析语句列表中的一条语句
+// 出错时跳过该语句剩余部分并返回 nil，使后续语句能够继续解析
+func (p *Parser) parseStatementSync() (stmt ast.Stmt) {
+	start := p.position
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(bailout); !ok {
+				panic(r)
+			}
+			p.noIn = false
+			if open, at, ok := p.unclosedBracket(start); ok {
+				// 后续语句被当作括号内的内容解析，错误改为报告在未闭合的括号处
+				p.errors[len(p.errors)-1] = verror.ParseVError{
+					Position: open.ToPosition(p.lexer.FileName()),
+					Message:  fmt.Sprintf("unclosed '%s'", open.Value),
+				}
+				p.position = at
+			} else {
+				p.synchronize(start)
+			}
+			stmt = nil
+		}
+	}()
+	return p.parseStatement()
+}
+
+func (p *Parser) parseStatement() (stmt ast.Stmt) {
 	tk := p.peek()
+	defer func() {
+		if stmt != nil {
+			p.finish(stmt, tk.Pos())
+		}
+	}()
 
 	if handlers, ok := p.handlers[tk.Type]; ok {
 		for _, handler := range handlers {
@@ -180,17 +466,35 @@ func (p *Parser) parseStatement() ast.Stmt {
 	return p.parseExpressionStatement()
 }
 
+// parseLoopLabel 解析 break/continue 之后同一行的可选标签
+func (p *Parser) parseLoopLabel() *ast.Literal {
+	if p.peek().Type == token.IDENT {
+		return p.createLiteral(p.advance())
+	}
+	return nil
+}
+
+// parseComment 解析单独成行的注释
+func (p *Parser) parseComment() *ast.CommentStmt {
+	tk := p.advance()
+	stmt := ast.NewCommentStmt(tk)
+	stmt.Span = ast.SpanOf(p.lexer.FileName(), tk)
+	return stmt
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStmt {
-	p.expect(token.COLON)
+	start := p.expect(token.COLON)
 	var body []ast.Stmt
 	for !p.isEof() && p.peek().Type != token.END {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementSync()
 		if stmt != nil {
 			body = append(body, stmt)
 		}
 	}
 	p.expect(token.END)
-	return ast.NewBlockStmt(body)
+	block := ast.NewBlockStmt(body)
+	p.finish(block, start.Pos())
+	return block
 }
 
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStmt {
@@ -203,76 +507,264 @@ func (p *Parser) parseExpression() ast.Expr {
 	return p.parseAssignmentExpression()
 }
 
+var assignOperators = []token.TokenType{
+	token.ASSIGN, token.INC_EQ, token.DEC_EQ, token.MUL_EQ, token.DIV_EQ, token.MOD_EQ, token.POW_EQ, token.INT_DIV_EQ,
+}
+
 func (p *Parser) parseAssignmentExpression() ast.Expr {
 	if p.isEof() {
 		return nil
 	}
-	left := p.parseLogicalExpression()
-	if p.peek().Type == token.ASSIGN {
-		op := p.expect(token.ASSIGN)
+	start := p.startPos()
+	left := p.parseTernaryExpression()
+	if slices.Contains(assignOperators, p.peek().Type) {
+		op := p.advance()
 		right := p.parseAssignmentExpression()
-		return ast.NewAssignmentExpr(left, right, op)
+		node := ast.NewAssignmentExpr(left, right, op)
+		p.finish(node, start)
+		return node
 	}
 	return left
 }
 
-func (p *Parser) parseLogicalExpression() ast.Expr {
-	if p.isEof() {
-		return nil
+// parseTernaryExpression 解析 cond ? a : b，右结合
+func (p *Parser) parseTernaryExpression() ast.Expr {
+	start := p.startPos()
+	cond := p.parseBinaryExpression(LOWEST)
+	if p.peek().Type != token.QUESTION {
+		return cond
 	}
-	left := p.parseCompareExpression()
-	if p.peek().Type == token.OR || p.peek().Type == token.AND {
-		op := p.advance()
-		right := p.parseLogicalExpression()
-		return ast.NewBinaryExpr(left, right, op)
+	p.advance()
+	// 分支内的 in 总是运算符
+	noIn := p.noIn
+	p.noIn = false
+	consequent := p.parseTernaryExpression()
+	p.noIn = noIn
+	p.expect(token.COLON)
+	alternate := p.parseTernaryExpression()
+	node := ast.NewTernaryExpr(cond, consequent, alternate)
+	p.finish(node, start)
+	return node
+}
+
+// 二元运算符优先级，数值越大结合越紧密
+const (
+	LOWEST      int = iota
+	COALESCE        // ??
+	LOGICAL_OR      // or
+	LOGICAL_AND     // and
+	EQUALS          // == !=
+	COMPARE         // < > <= >= in is
+	RANGE           // .. ..<
+	BIT_OR          // |
+	BIT_XOR         // ^
+	BIT_AND         // &
+	SHIFT           // << >>
+	SUM             // + -
+	PRODUCT         // * / // %
+	PREFIX          // -x ~x
+	POWER           // **
+)
+
+var precedences = map[token.TokenType]int{
+	token.COALESCE:   COALESCE,
+	token.OR:         LOGICAL_OR,
+	token.AND:        LOGICAL_AND,
+	token.EQ:         EQUALS,
+	token.NOT_EQ:     EQUALS,
+	token.LESS:       COMPARE,
+	token.LESS_EQ:    COMPARE,
+	token.GREATER:    COMPARE,
+	token.GREATER_EQ: COMPARE,
+	token.IN:         COMPARE,
+	token.IS:         COMPARE,
+	token.DOTDOT:     RANGE,
+	token.DOTDOT_LT:  RANGE,
+	token.BIT_OR:     BIT_OR,
+	token.BIT_XOR:    BIT_XOR,
+	token.BIT_AND:    BIT_AND,
+	token.SHL:        SHIFT,
+	token.SHR:        SHIFT,
+	token.PLUS:       SUM,
+	token.MINUS:      SUM,
+	token.MUL:        PRODUCT,
+	token.DIV:        PRODUCT,
+	token.INT_DIV:    PRODUCT,
+	token.MOD:        PRODUCT,
+	token.POW:        POWER,
+}
+
+// 比较运算符生成 CompareExpr，其余生成 BinaryExpr
+var compareOperators = []token.TokenType{
+	token.EQ, token.NOT_EQ, token.LESS, token.LESS_EQ, token.GREATER, token.GREATER_EQ,
+}
+
+func (p *Parser) peekPrecedence() int {
+	tk := p.peek()
+	// for ... in 的循环变量部分不把 in 当作运算符
+	if tk.Type == token.IN && p.noIn {
+		return LOWEST
 	}
-	return left
+	if prec, ok := precedences[tk.Type]; ok {
+		return prec
+	}
+	return LOWEST
 }
 
-func (p *Parser) parseCompareExpression() ast.Expr {
+// parseBinaryExpression 按优先级解析二元表达式，只结合优先级高于 minPrec 的运算符
+func (p *Parser) parseBinaryExpression(minPrec int) ast.Expr {
 	if p.isEof() {
 		return nil
 	}
-	left := p.parseBinaryExpression()
-	if p.peek().Type == token.EQ || p.peek().Type == token.NOT_EQ || p.peek().Type == token.LESS_EQ || p.peek().Type == token.GREATER_EQ || p.peek().Type == token.LESS || p.peek().Type == token.GREATER {
+	// 范围从最左侧操作数开始，包含其括号
+	start := p.startPos()
+	left := p.parseUnaryExpression()
+	for {
+		prec := p.peekPrecedence()
+		if prec <= minPrec {
+			return left
+		}
 		op := p.advance()
-		right := p.parseCompareExpression()
-		return ast.NewCompareExpr(left, right, op)
+		if op.Type == token.IS {
+			// is 的右侧为类型名
+			name := p.parseTypeName()
+			pattern := ast.NewTypePattern(name)
+			pattern.Span = name.Span
+			left = ast.NewBinaryExpr(left, pattern, op)
+			p.finish(left, start)
+			continue
+		}
+		// ** 为右结合，其余运算符左结合
+		if op.Type == token.POW {
+			prec--
+		}
+		right := p.parseBinaryExpression(prec)
+		if op.Type == token.DOTDOT || op.Type == token.DOTDOT_LT {
+			left = p.parseRangeStep(left, right, op)
+		} else if slices.Contains(compareOperators, op.Type) {
+			left = ast.NewCompareExpr(left, right, op)
+		} else {
+			left = ast.NewBinaryExpr(left, right, op)
+		}
+		p.finish(left, start)
 	}
-	return left
 }
 
-func (p *Parser) parseBinaryExpression() ast.Expr {
-	if p.isEof() {
-		return nil
+// parseRangeStep 解析区间末尾可选的 step，step 只在此处作为关键字
+func (p *Parser) parseRangeStep(start, end ast.Expr, op Token) ast.Expr {
+	var step ast.Expr
+	if tk := p.peek(); tk.Type == token.IDENT && tk.Value == "step" {
+		p.advance()
+		step = p.parseBinaryExpression(RANGE)
 	}
-	return p.parseAdditiveExpression()
+	return ast.NewRangeExpr(start, end, step, op.Type == token.DOTDOT)
 }
 
-func (p *Parser) parseAdditiveExpression() ast.Expr {
-	if p.isEof() {
-		return nil
-	}
-	left := p.parseMultiplicativeExpression()
-	for p.peek().Type == token.PLUS || p.peek().Type == token.MINUS {
+func (p *Parser) parseUnaryExpression() ast.Expr {
+	switch p.peek().Type {
+	case token.NOT:
+		// not 的优先级低于比较运算，not a == b 为 not (a == b)
 		op := p.advance()
-		right := p.parseMultiplicativeExpression()
-		left = ast.NewBinaryExpr(left, right, op)
+		right := p.parseBinaryExpression(LOGICAL_AND)
+		node := ast.NewUnaryExpr(right, op, false)
+		p.finish(node, op.Pos())
+		return node
+	case token.BANG, token.MINUS, token.BIT_NOT, token.DEC, token.INC, token.TYPEOF:
+		op := p.advance()
+		// 操作数可以包含 **，因此 -2 ** 2 为 -(2 ** 2)
+		right := p.parseBinaryExpression(PREFIX)
+		node := ast.NewUnaryExpr(right, op, false)
+		p.finish(node, op.Pos())
+		return node
 	}
-	return left
+	start := p.startPos()
+	return p.parsePostfixExpression(p.parsePrimaryExpression(), start)
 }
 
-func (p *Parser) parseMultiplicativeExpression() ast.Expr {
-	if p.isEof() {
+// parsePostfixExpression 解析成员访问、下标、调用与后缀自增自减，均为左结合
+// 后缀节点的范围从最左侧操作数的 start 开始
+func (p *Parser) parsePostfixExpression(left ast.Expr, start token.Pos) ast.Expr {
+	var chained bool
+	if left == nil {
 		return nil
 	}
-	left := p.parseCallExpression()
-	for p.peek().Type == token.MUL || p.peek().Type == token.DIV {
-		op := p.advance()
-		right := p.parseCallExpression()
-		left = ast.NewBinaryExpr(left, right, op)
+	for {
+		switch p.peek().Type {
+		case token.DOT:
+			p.advance()
+			left = ast.NewMemberExpr(left, p.parsePropertyName(), false)
+		case token.LBRACKET:
+			p.advance()
+			prop := p.parseExpression()
+			p.expect(token.RBRACKET)
+			left = ast.NewMemberExpr(left, prop, true)
+		case token.LPAREN:
+			left = p.parseCallExpression(left, false, start)
+		case token.OPT_CHAIN:
+			// a?.b a?.[i] f?.()
+			p.advance()
+			chained = true
+			switch p.peek().Type {
+			case token.LBRACKET:
+				p.advance()
+				prop := p.parseExpression()
+				p.expect(token.RBRACKET)
+				member := ast.NewMemberExpr(left, prop, true)
+				member.Optional = true
+				left = member
+			case token.LPAREN:
+				left = p.parseCallExpression(left, true, start)
+			default:
+				member := ast.NewMemberExpr(left, p.parsePropertyName(), false)
+				member.Optional = true
+				left = member
+			}
+		case token.INC, token.DEC:
+			if chained {
+				p.errorf(p.peek(), "invalid operand for %s: optional chain", p.peek().Value)
+			}
+			left = ast.NewUnaryExpr(left, p.advance(), true)
+		default:
+			if chained {
+				left = ast.NewChainExpr(left)
+				p.finish(left, start)
+			}
+			return left
+		}
+		p.finish(left, start)
 	}
-	return left
+}
+
+// parsePropertyName 解析 '.' 之后的属性名，关键字也可以作为属性名
+func (p *Parser) parsePropertyName() *ast.Literal {
+	tk := p.peek()
+	if tk.Type == token.IDENT || tk.Type == token.INT {
+		return p.createLiteral(p.advance())
+	}
+	if token.LookupIdent(tk.Value) == tk.Type {
+		p.advance()
+		tk.Type = token.IDENT
+		return p.createLiteral(tk)
+	}
+	p.errorf(tk, "expected property name after '.', got %s", tk.Type)
+	return nil
+}
+
+// isKeywordKey 判断当前 token 是否为用作键名的关键字，即关键字之后紧跟 ':' 或 as，例如 {type: 1}
+func (p *Parser) isKeywordKey() bool {
+	tk := p.peek()
+	next := p.peekIndex(1).Type
+	return tk.Type != token.IDENT && token.LookupIdent(tk.Value) == tk.Type && (next == token.COLON || next == token.AS)
+}
+
+// expectKey 读取对象模式中的键，用作键名的关键字视为标识符
+func (p *Parser) expectKey() Token {
+	if p.isKeywordKey() {
+		tk := p.advance()
+		tk.Type = token.IDENT
+		return tk
+	}
+	return p.expect(token.IDENT, token.STRING, token.INT)
 }
 
 func (p *Parser) parseArgs() *ast.ArgsExpr {
@@ -280,8 +772,34 @@ func (p *Parser) parseArgs() *ast.ArgsExpr {
 		return nil
 	}
 	var node = ast.NewArgsExpr([]ast.Expr{})
-	for !p.isEof() && p.peek().Type != token.RPAREN {
-		expr := p.parseExpression()
+	var named bool
+	for !p.isEof() {
+		// 参数列表可以跨行
+		for p.peek().Type == token.NEWLINE {
+			p.advance()
+		}
+		if p.peek().Type == token.RPAREN {
+			break
+		}
+		var expr ast.Expr
+		if p.peek().Type == token.IDENT && p.peekIndex(1).Type == token.COLON {
+			// 具名参数 name: value
+			name := p.createLiteral(p.advance())
+			p.advance()
+			expr = ast.NewNamedArg(name, p.parseExpression())
+			named = true
+		} else {
+			if named {
+				p.errorf(p.peek(), "positional argument follows named argument")
+			}
+			if p.peek().Type == token.ELLIPSIS {
+				op := p.advance()
+				expr = ast.NewSpreadExpr(p.parseExpression())
+				p.finish(expr, op.Pos())
+			} else {
+				expr = p.parseExpression()
+			}
+		}
 		if expr == nil {
 			break
 		}
@@ -293,77 +811,205 @@ func (p *Parser) parseArgs() *ast.ArgsExpr {
 	return node
 }
 
-func (p *Parser) parseCallExpression() ast.Expr {
-	if p.isEof() {
-		return nil
-	}
-	left := p.parseMemberExpression()
-	for p.peek().Type == token.LPAREN {
+func (p *Parser) parseCallExpression(callee ast.Expr, optional bool, start token.Pos) ast.Expr {
+	lparen := p.expect(token.LPAREN)
+	args := p.parseArgs()
+	p.expect(token.RPAREN)
+	p.finish(args, lparen.Pos())
+	left := ast.NewCallExpr(callee, *args)
+	left.Optional = optional
+	p.finish(left, start)
+
+	// to 链可以从下一行开始
+	if p.peek().Type == token.NEWLINE && p.peekIndex(1).Type == token.TO {
 		p.advance()
+	}
+
+	if p.peek().Type != token.TO {
+		return left
+	}
+
+	var parentToStmt = ast.NewToExpr(*ast.NewBlockStmt([]ast.Stmt{}), *ast.NewArgsExpr([]ast.Expr{}), nil)
+	var currentToStmt = parentToStmt
+	for p.peek().Type == token.TO {
+		to := p.advance()
+		toStmt := ast.NewToExpr(*ast.NewBlockStmt([]ast.Stmt{}), *ast.NewArgsExpr([]ast.Expr{}), nil)
+		if p.peek().Type == token.LPAREN {
+			lparen := p.advance()
+			args := p.parseArgs()
+			p.expect(token.RPAREN)
+			p.finish(args, lparen.Pos())
+			toStmt.Args = *args
+		}
+		colon := p.expect(token.COLON)
+		var block = ast.NewBlockStmt([]ast.Stmt{})
+		for !slices.Contains([]token.TokenType{token.TO, token.END, token.CATCH}, p.peek().Type) && !p.isEof() {
+			stmt := p.parseStatementSync()
+			if stmt != nil {
+				block.Body = append(block.Body, stmt)
+			}
+		}
+		p.finish(block, colon.Pos())
+		toStmt.Body = *block
+		p.finish(toStmt, to.Pos())
+		currentToStmt.Next = toStmt
+		currentToStmt = toStmt
+	}
+	var catchStmt *ast.LambdaFunctionDecl
+	if p.peek().Type == token.CATCH {
+		catch := p.advance()
+		lparen := p.expect(token.LPAREN)
 		args := p.parseArgs()
 		p.expect(token.RPAREN)
-		left = ast.NewCallExpr(left, *args)
+		p.finish(args, lparen.Pos())
+		colon := p.expect(token.COLON)
+		var blockStmt = ast.NewBlockStmt([]ast.Stmt{})
+		for !p.isEof() && p.peek().Type != token.END {
+			stmt := p.parseStatementSync()
+			if stmt != nil {
+				blockStmt.Body = append(blockStmt.Body, stmt)
+			}
+		}
+		p.finish(blockStmt, colon.Pos())
+		catchStmt = ast.NewLambdaFunctionDecl(*args, *blockStmt)
+		p.finish(catchStmt, catch.Pos())
+	}
+	p.expect(token.END)
 
-		// 可能是换行
-		if p.peek().Type == token.NEWLINE {
+	task := ast.NewCallTaskFn(*left, *parentToStmt.Next, catchStmt)
+	p.finish(task, start)
+	return task
+}
+
+// parseParamList 解析函数声明中可省略的括号参数列表，省略时为空列表
+func (p *Parser) parseParamList() *ast.ArgsExpr {
+	if p.peek().Type != token.LPAREN {
+		args := ast.NewArgsExpr([]ast.Expr{})
+		p.finish(args, p.lastEnd())
+		return args
+	}
+	lparen := p.advance()
+	args := p.parseParams()
+	p.expect(token.RPAREN)
+	p.finish(args, lparen.Pos())
+	return args
+}
+
+// parseParams 解析函数声明的参数列表，参数可以是标识符或解构模式
+func (p *Parser) parseParams() *ast.ArgsExpr {
+	var node = ast.NewArgsExpr([]ast.Expr{})
+	for !p.isEof() {
+		for p.peek().Type == token.NEWLINE {
 			p.advance()
 		}
+		if p.peek().Type == token.RPAREN {
+			break
+		}
+		node.Arguments = append(node.Arguments, p.parseParam(node.Arguments))
+		for p.peek().Type == token.NEWLINE {
+			p.advance()
+		}
+		if p.peek().Type != token.COMMA {
+			break
+		}
+		p.advance()
+	}
+	return node
+}
+
+// parseParam 解析单个参数：name、name = default 或 ...rest
+// 剩余参数必须位于最后且不能有默认值，已有默认值后的参数也必须带默认值
+func (p *Parser) parseParam(prev []ast.Expr) ast.Expr {
+	if len(prev) > 0 && prev[len(prev)-1].(*ast.Parameter).Rest {
+		p.errorf(p.peek(), "rest parameter must be the last parameter")
+	}
+	tk := p.peek()
+	if tk.Type == token.ELLIPSIS {
+		p.advance()
+		param := ast.NewParameter(p.createLiteral(p.expect(token.IDENT)), nil, true)
+		p.finish(param, tk.Pos())
+		return param
+	}
+	name := p.parseBindingTarget()
+	var def ast.Expr
+	if p.peek().Type == token.ASSIGN {
+		p.advance()
+		def = p.parseTernaryExpression()
+	} else if len(prev) > 0 && prev[len(prev)-1].(*ast.Parameter).Default != nil {
+		p.errorf(tk, "parameter without default value follows parameter with default value")
+	}
+	param := ast.NewParameter(name, def, false)
+	p.finish(param, tk.Pos())
+	return param
+}
 
-		var parentToStmt = ast.NewToExpr(*ast.NewBlockStmt([]ast.Stmt{}), *ast.NewArgsExpr([]ast.Expr{}), nil)
-		var currentToStmt = parentToStmt
-		if p.peek().Type == token.TO {
-			for p.peek().Type == token.TO || p.peek().Type != token.CATCH {
+// parseBindingTarget 解析绑定目标：标识符、数组解构或对象解构
+func (p *Parser) parseBindingTarget() ast.Expr {
+	tk := p.peek()
+	switch tk.Type {
+	case token.IDENT:
+		return p.createLiteral(p.advance())
+	case token.LBRACKET, token.LBRACE:
+		return p.parsePattern()
+	}
+	p.errorf(tk, "expected identifier or destructuring pattern, got %s", tk.Type)
+	return nil
+}
+
+// parsePattern 解析 [a, b] 或 {a, b as c, d: [e]} 形式的解构模式
+func (p *Parser) parsePattern() ast.Expr {
+	open := p.advance()
+	closing := token.RBRACKET
+	if open.Type == token.LBRACE {
+		closing = token.RBRACE
+	}
+
+	var elements []ast.Expr
+	var properties []*ast.Property
+	for {
+		for p.peek().Type == token.NEWLINE || p.peek().IsComment() {
+			p.advance()
+		}
+		if p.peek().Type == closing {
+			break
+		}
+		if open.Type == token.LBRACKET {
+			elements = append(elements, p.parseBindingTarget())
+		} else {
+			keyTk := p.expectKey()
+			key := p.createLiteral(keyTk)
+			var target ast.Expr
+			switch p.peek().Type {
+			case token.AS:
 				p.advance()
-				toStmt := ast.NewToExpr(*ast.NewBlockStmt([]ast.Stmt{}), *ast.NewArgsExpr([]ast.Expr{}), nil)
-				if p.peek().Type == token.LPAREN {
-					p.advance()
-					args := p.parseArgs()
-					p.expect(token.RPAREN)
-					toStmt.Args = *args
-				}
-				p.expect(token.COLON)
-				var block = ast.NewBlockStmt([]ast.Stmt{})
-				for !slices.Contains([]token.TokenType{token.TO, token.END, token.CATCH}, p.peek().Type) && !p.isEof() {
-					stmt := p.parseStatement()
-					if stmt != nil {
-						block.Body = append(block.Body, stmt)
-					}
-				}
-				toStmt.Body = *block
-				currentToStmt.Next = toStmt
-				if p.peek().Type == token.TO {
-					currentToStmt = toStmt
-				}
-			}
-			var catchStmt *ast.LambdaFunctionDecl
-			if p.peek().Type == token.CATCH {
+				target = p.createLiteral(p.expect(token.IDENT))
+			case token.COLON:
 				p.advance()
-				p.expect(token.LPAREN)
-				args := p.parseArgs()
-				p.expect(token.RPAREN)
-				p.expect(token.COLON)
-				var blockStmt = ast.NewBlockStmt([]ast.Stmt{})
-				for !p.isEof() && p.peek().Type != token.END {
-					stmt := p.parseStatement()
-					if stmt != nil {
-						blockStmt.Body = append(blockStmt.Body, stmt)
-					}
+				target = p.parseBindingTarget()
+			default:
+				if keyTk.Type != token.IDENT {
+					p.errorf(keyTk, "property %s must be bound with 'as' or ':'", keyTk.Value)
 				}
-				catchStmt = ast.NewLambdaFunctionDecl(*args, *blockStmt)
+				target = key
 			}
-
-			p.expect(token.END)
-
-			target, ok := left.(*ast.CallExpr)
-			if !ok {
-				return left
-			}
-
-			return ast.NewCallTaskFn(*target, *parentToStmt.Next, catchStmt)
+			properties = append(properties, ast.NewProperty(key, target))
 		}
+		for p.peek().Type == token.NEWLINE {
+			p.advance()
+		}
+		if p.peek().Type != token.COMMA {
+			break
+		}
+		p.advance()
 	}
+	p.expect(closing)
 
-	return left
+	var pattern ast.Expr = ast.NewObjectPattern(properties)
+	if open.Type == token.LBRACKET {
+		pattern = ast.NewArrayPattern(elements)
+	}
+	p.finish(pattern, open.Pos())
+	return pattern
 }
 
 func (p *Parser) parseLambda() *ast.LambdaFunctionDecl {
@@ -371,36 +1017,47 @@ func (p *Parser) parseLambda() *ast.LambdaFunctionDecl {
 		return nil
 	}
 	p.expect(token.FN)
-	var args = ast.NewArgsExpr([]ast.Expr{})
-	if p.peek().Type == token.LPAREN {
-		p.expect(token.LPAREN)
-		args = p.parseArgs()
-		p.expect(token.RPAREN)
-	}
+	args := p.parseParamList()
 	body := p.parseBlockStatement()
 	return ast.NewLambdaFunctionDecl(*args, *body)
 }
 
-func (p *Parser) parsePropertyExpression() []*ast.Property {
+func (p *Parser) parsePropertyExpression(isObject bool) []*ast.Property {
 	var properties = make([]*ast.Property, 0)
 	if p.isEof() {
 		return properties
 	}
 	var index = 0
-	for p.peek().Type != token.RBRACE && p.peek().Type != token.RBRACKET {
-		key := p.parseExpression()
-		if p.peek().Type == token.COLON {
+	for tk := p.peek().Type; tk != token.RBRACE && tk != token.RBRACKET && tk != token.EOF; tk = p.peek().Type {
+		if p.peek().Type == token.ELLIPSIS {
+			// 展开 ...value，对象中的展开没有键
+			op := p.advance()
+			spread := ast.NewSpreadExpr(p.parseExpression())
+			p.finish(spread, op.Pos())
+			var key *ast.Literal
+			if !isObject {
+				key = p.indexKey(index, spread)
+			}
+			properties = append(properties, ast.NewProperty(key, spread))
+			if p.peek().Type == token.COMMA {
+				p.advance()
+			}
+		} else if keyTk, key := p.peek(), p.parsePropertyKey(); p.peek().Type == token.COLON {
+			lit, ok := key.(*ast.Literal)
+			if !ok {
+				p.errorf(keyTk, "invalid object key, expected a name, string or number")
+			}
 			p.advance()
 			value := p.parseExpression()
 			if p.peek().Type == token.COMMA {
 				p.advance()
 			}
-			properties = append(properties, ast.NewProperty(key.(*ast.Literal), value))
+			properties = append(properties, ast.NewProperty(lit, value))
 		} else {
 			if p.peek().Type == token.COMMA {
 				p.advance()
 			}
-			properties = append(properties, ast.NewProperty(p.createLiteral(token.Token{Type: token.INT, Value: fmt.Sprint(index)}), key))
+			properties = append(properties, ast.NewProperty(p.indexKey(index, key), key))
 		}
 		index++
 		// 跳过换行符
@@ -411,11 +1068,28 @@ func (p *Parser) parsePropertyExpression() []*ast.Property {
 	return properties
 }
 
+// parsePropertyKey 解析对象字面量中 ':' 之前的部分，用作键名的关键字视为标识符
+func (p *Parser) parsePropertyKey() ast.Expr {
+	if p.isKeywordKey() {
+		tk := p.advance()
+		tk.Type = token.IDENT
+		return p.createLiteral(tk)
+	}
+	return p.parseExpression()
+}
+
+// indexKey 创建数组元素的下标键，键没有对应的源码，范围取元素本身
+func (p *Parser) indexKey(index int, elem ast.Expr) *ast.Literal {
+	key := p.createLiteral(token.Token{Type: token.INT, Value: fmt.Sprint(index)})
+	key.Span = elem.NodeSpan()
+	return key
+}
+
 func (p *Parser) parseArrayExpression() ast.Expr {
 	if p.isEof() {
 		return nil
 	}
-	args := p.parsePropertyExpression()
+	args := p.parsePropertyExpression(false)
 	arr := ast.NewArrayExpr(args)
 	return arr
 }
@@ -424,47 +1098,17 @@ func (p *Parser) parseObjectExpression() ast.Expr {
 	if p.isEof() {
 		return nil
 	}
-	args := p.parsePropertyExpression()
+	args := p.parsePropertyExpression(true)
 	obj := ast.NewObjectExpr(args)
 	return obj
 }
 
-func (p *Parser) parseMemberExpression() ast.Expr {
-	if p.isEof() {
-		return nil
-	}
-	left := p.parseSuffixExpression()
-	if p.peek().Type == token.DOT {
-		p.advance()
-		right := p.parseMemberExpression()
-		return ast.NewMemberExpr(left, right, false)
-	} else if p.peek().Type == token.LBRACKET {
-		p.advance()
-		right := p.parseMemberExpression()
-		p.expect(token.RBRACKET)
-		return ast.NewMemberExpr(left, right, true)
-	}
-	return left
-}
-
-func (p *Parser) parseSuffixExpression() ast.Expr {
-	if p.isEof() {
-		return nil
-	}
-	left := p.parsePrimaryExpression()
-	if p.peek().Type == token.INC || p.peek().Type == token.DEC {
-		op := p.advance()
-		return ast.NewUnaryExpr(left, op, true)
-	}
-	return left
-}
-
 func (p *Parser) parseSwitchCase() ast.Expr {
 	if p.isEof() {
 		return nil
 	}
 	var kw Token
-	for p.peek().Type == token.NEWLINE || p.peek().Type == token.WHITESPACE || p.peek().Type == token.COMMENT {
+	for p.peek().Type == token.NEWLINE || p.peek().Type == token.WHITESPACE || p.peek().IsComment() {
 		p.advance()
 	}
 	if p.peek().Type == token.CASE || p.peek().Type == token.DEFAULT {
@@ -484,31 +1128,223 @@ func (p *Parser) parseSwitchCase() ast.Expr {
 			node.Conds = append(node.Conds, cond)
 		}
 	}
-	p.expect(token.COLON)
+	colon := p.expect(token.COLON)
 
 	/* 解析body */
 	var body []ast.Stmt
 	for !p.isEof() && !slices.Contains([]token.TokenType{token.DEFAULT, token.CASE, token.END}, p.peek().Type) {
-		stmt := p.parseStatement()
+		stmt := p.parseStatementSync()
 		if stmt != nil {
 			body = append(body, stmt)
 		}
 	}
 
 	node.Body = ast.NewBlockStmt(body)
+	p.finish(node.Body, colon.Pos())
+	p.finish(node, kw.Pos())
 	return node
 }
 
-func (p *Parser) parsePrimaryExpression() ast.Expr {
+// parseMatchExpression 解析 match 表达式
+//
+//	match value:
+//	    case 0, 1: "small"
+//	    case [x, y] if x > y: x
+//	    case _: "other"
+//	end
+func (p *Parser) parseMatchExpression() ast.Expr {
+	p.advance() // skip 'match'
+	subject := p.parseExpression()
+	p.expect(token.COLON)
+	var arms []*ast.MatchArm
+	for {
+		for p.peek().Type == token.NEWLINE || p.peek().IsComment() {
+			p.advance()
+		}
+		if p.isEof() || p.peek().Type == token.END {
+			break
+		}
+		kw := p.expect(token.CASE)
+		var patterns = []ast.Expr{p.parseMatchPattern()}
+		for p.peek().Type == token.COMMA {
+			p.advance()
+			patterns = append(patterns, p.parseMatchPattern())
+		}
+		var guard ast.Expr
+		if p.peek().Type == token.IF {
+			p.advance()
+			guard = p.parseExpression()
+		}
+		colon := p.expect(token.COLON)
+		if len(arms) > 0 && arms[len(arms)-1].Guard == nil {
+			p.checkReachable(arms[len(arms)-1], kw)
+		}
+
+		var body []ast.Stmt
+		for !p.isEof() && !slices.Contains([]token.TokenType{token.CASE, token.END}, p.peek().Type) {
+			stmt := p.parseStatementSync()
+			if stmt != nil {
+				body = append(body, stmt)
+			}
+		}
+		block := ast.NewBlockStmt(body)
+		p.finish(block, colon.Pos())
+		arm := ast.NewMatchArm(patterns, guard, block)
+		p.finish(arm, kw.Pos())
+		arms = append(arms, arm)
+	}
+	p.expect(token.END)
+	return ast.NewMatchExpr(subject, arms)
+}
+
+// parseMatchPattern 解析单个匹配模式：
+// 标识符绑定（_ 为通配符）、数组与对象模式、typeof 类型模式，其余为值模式（字面量或区间）
+// 单独的标识符总是绑定新变量，与变量的值比较需要写成 ^name，限定名 a.b 与 lo..hi 是值模式
+func (p *Parser) parseMatchPattern() (pattern ast.Expr) {
 	tk := p.peek()
+	defer func() {
+		if pattern != nil {
+			p.finish(pattern, tk.Pos())
+		}
+	}()
+	switch tk.Type {
+	case token.IDENT:
+		if next := p.peekIndex(1).Type; next != token.DOT && next != token.DOTDOT && next != token.DOTDOT_LT {
+			return p.createLiteral(p.advance())
+		}
+	case token.BIT_XOR:
+		p.advance()
+		return ast.NewPinPattern(p.createLiteral(p.expect(token.IDENT)))
+	case token.TYPEOF:
+		p.advance()
+		return ast.NewTypePattern(p.parseTypeName())
+	case token.LBRACKET:
+		p.advance()
+		var elements []ast.Expr
+		for {
+			for p.peek().Type == token.NEWLINE {
+				p.advance()
+			}
+			if p.peek().Type == token.RBRACKET {
+				break
+			}
+			if p.peek().Type == token.ELLIPSIS {
+				// 剩余元素 ...rest，只能位于末尾
+				op := p.advance()
+				rest := ast.NewSpreadExpr(p.createLiteral(p.expect(token.IDENT)))
+				p.finish(rest, op.Pos())
+				elements = append(elements, rest)
+				break
+			}
+			elements = append(elements, p.parseMatchPattern())
+			if p.peek().Type != token.COMMA {
+				break
+			}
+			p.advance()
+		}
+		p.expect(token.RBRACKET)
+		return ast.NewArrayPattern(elements)
+	case token.LBRACE:
+		p.advance()
+		var properties []*ast.Property
+		for {
+			for p.peek().Type == token.NEWLINE {
+				p.advance()
+			}
+			if p.peek().Type == token.RBRACE {
+				break
+			}
+			keyTk := p.expectKey()
+			key := p.createLiteral(keyTk)
+			var target ast.Expr
+			switch p.peek().Type {
+			case token.AS:
+				p.advance()
+				target = p.createLiteral(p.expect(token.IDENT))
+			case token.COLON:
+				p.advance()
+				target = p.parseMatchPattern()
+			default:
+				if keyTk.Type != token.IDENT {
+					p.errorf(keyTk, "property %s must be matched with 'as' or ':'", keyTk.Value)
+				}
+				target = key
+			}
+			properties = append(properties, ast.NewProperty(key, target))
+			for p.peek().Type == token.NEWLINE {
+				p.advance()
+			}
+			if p.peek().Type != token.COMMA {
+				break
+			}
+			p.advance()
+		}
+		p.expect(token.RBRACE)
+		return ast.NewObjectPattern(properties)
+	}
+	return p.parseBinaryExpression(LOWEST)
+}
+
+// checkReachable 前一个分支没有守卫且某个模式是单独的标识符时，它匹配所有值，后面的分支 kw 永远不会执行
+// 这通常是想与常量比较却写成了绑定，报告在该模式处
+func (p *Parser) checkReachable(prev *ast.MatchArm, kw Token) {
+	for _, pattern := range prev.Patterns {
+		if lit, ok := pattern.(*ast.Literal); ok && lit.Value.Type == token.IDENT {
+			name := lit.Value.Value
+			if name == "_" {
+				p.errorf(kw, "unreachable match arm: the previous arm matches every value with _")
+			}
+			p.errorf(*lit.Value, "pattern %s binds every value, so later arms are unreachable; use ^%s to compare with the value of %s", name, name, name)
+		}
+	}
+}
+
+// parseTypeName 解析 typeof 模式与 is 之后的类型名，nil 也可以作为类型名
+func (p *Parser) parseTypeName() *ast.Literal {
+	name := p.expect(token.IDENT, token.NIL)
+	name.Type = token.IDENT
+	return p.createLiteral(name)
+}
+
+// parseTemplateLiteral 解析模板字符串，片段与插值表达式交替出现
+func (p *Parser) parseTemplateLiteral() ast.Expr {
+	head := p.advance()
+	quotes := []ast.Node{ast.NewTemplateElement(p.createLiteral(head))}
+	if head.Type == token.TEMPLATE {
+		return ast.NewTemplateLiteralExpr(quotes)
+	}
+	for {
+		quotes = append(quotes, p.parseExpression())
+		part := p.expect(token.TEMPLATE_MIDDLE, token.TEMPLATE_TAIL)
+		quotes = append(quotes, ast.NewTemplateElement(p.createLiteral(part)))
+		if part.Type == token.TEMPLATE_TAIL {
+			break
+		}
+	}
+	return ast.NewTemplateLiteralExpr(quotes)
+}
+
+func (p *Parser) parsePrimaryExpression() (expr ast.Expr) {
+	tk := p.peek()
+	defer func() {
+		if expr != nil {
+			p.finish(expr, tk.Pos())
+		}
+	}()
 
 	switch tk.Type {
 	case token.IDENT, token.STRING, token.INT, token.FLOAT, token.NIL, token.TRUE, token.FALSE:
 		p.advance()
 		return p.createLiteral(tk)
+	case token.TEMPLATE, token.TEMPLATE_HEAD:
+		return p.parseTemplateLiteral()
 	case token.LPAREN:
 		p.advance()
+		// 括号内的 in 总是运算符
+		noIn := p.noIn
+		p.noIn = false
 		expr := p.parseExpression()
+		p.noIn = noIn
 		p.expect(token.RPAREN)
 		return expr
 	case token.LBRACKET:
@@ -521,17 +1357,15 @@ func (p *Parser) parsePrimaryExpression() ast.Expr {
 		expr := p.parseObjectExpression()
 		p.expect(token.RBRACE)
 		return expr
-	case token.NEWLINE, token.WHITESPACE, token.COMMENT:
+	case token.NEWLINE, token.WHITESPACE, token.COMMENT, token.BLOCK_COMMENT, token.DOC_COMMENT:
 		p.advance()
 		return p.parsePrimaryExpression()
-	case token.NOT, token.MINUS, token.DEC, token.INC:
-		op := p.advance()
-		right := p.parseSuffixExpression()
-		return ast.NewUnaryExpr(right, op, false)
 	case token.WAIT:
 		return p.CallStmtHandler(token.WAIT)
 	case token.FN:
 		return p.parseLambda()
+	case token.MATCH:
+		return p.parseMatchExpression()
 	default:
 		p.errorf(tk, "primary unexpected token: %s", tk.String())
 		return nil
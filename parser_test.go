@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"vine-lang/lexer"
+	"vine-lang/parser"
+	"vine-lang/verror"
+)
+
+// parseErrors 解析代码并返回所有解析错误，解析器内部的 panic 视为测试失败
+func parseErrors(t *testing.T, code string) verror.ErrorList {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("%q: parser panicked: %v", code, r)
+		}
+	}()
+	lex := lexer.New("parse.vine", code)
+	lex.Parse()
+	p := parser.CreateParser(lex)
+	p.ParseProgram()
+	var list verror.ErrorList
+	if err := p.Err(); err != nil && !errors.As(err, &list) {
+		t.Fatalf("%q: unexpected error type %T", code, err)
+	}
+	return list
+}
+
+// TestParseErrors 测试不完整或非法的输入报告解析错误而不是崩溃
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"let a = [1, 2", "[Line 1, Column 14] Parser Error: expected next token to be []], got EOF instead"},
+		{"let a = {x: 1", "[Line 1, Column 14] Parser Error: expected next token to be [}], got EOF instead"},
+		{"let a = {x: [1,\n", "[Line 2, Column 1] Parser Error: expected next token to be []], got EOF instead"},
+		{"let a = {b + 1: 2}\n", "[Line 1, Column 10] Parser Error: invalid object key, expected a name, string or number"},
+	}
+	for _, tt := range tests {
+		list := parseErrors(t, tt.code)
+		if len(list) == 0 {
+			t.Errorf("%q: expected a parse error", tt.code)
+			continue
+		}
+		if got := list[0].Error(); !strings.Contains(got, tt.want) {
+			t.Errorf("%q: error = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestParseRecovery 测试块的头部出错时跳过整个块，只报告一条错误
+func TestParseRecovery(t *testing.T) {
+	tests := []struct {
+		code string
+		want []string
+	}{
+		{"fn f(:\n    1\nend\nlet x = 1\n", []string{"[Line 1, Column 6]"}},
+		{"type A(:\n    let x = 1\nend\n", []string{"[Line 1, Column 8]"}},
+		{"if 1 +:\n    1\nelse if 2:\n    3\nend\nlet x = 1\n", []string{"[Line 1, Column 7]"}},
+		{"while (:\n    if x: 1 end\n    let g = fn(): 2 end\nend\n", []string{"[Line 1, Column 8]"}},
+		{"if true:\n    fn g(:\n        1\n    end\n    let y = 1 +\nend\n", []string{"[Line 2, Column 10]", "[Line 6, Column 1]"}},
+		{"fn f():\n    let a = (\nend\nlet b = )\n", []string{"[Line 2, Column 13] Parser Error: unclosed '('", "[Line 4, Column 9]"}},
+		{"let a = (1 +\nlet b = [1, 2\nfn f(:\n", []string{"[Line 1, Column 9] Parser Error: unclosed '('", "[Line 2, Column 9] Parser Error: unclosed '['", "[Line 3, Column 6]"}},
+		{"let xs = map([1], fn(x):\n    let y = x +\nend)\nlet b = {k: 1\nreturn b\n", []string{"[Line 3, Column 1]", "[Line 4, Column 9] Parser Error: unclosed '{'"}},
+	}
+	for _, tt := range tests {
+		list := parseErrors(t, tt.code)
+		if len(list) != len(tt.want) {
+			t.Errorf("%q: got %d errors, want %d: %v", tt.code, len(list), len(tt.want), list)
+			continue
+		}
+		for index, want := range tt.want {
+			if got := list[index].Error(); !strings.Contains(got, want) {
+				t.Errorf("%q: error %d = %q, want %s", tt.code, index, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"vine-lang/object/ranges"
+)
+
+// TestRanges 测试区间的边界、步长、切片与成员检测
+func TestRanges(t *testing.T) {
+	checkValues(t, "range.vine", []valueTest{
+		{"[...1..3]", []any{int64(1), int64(2), int64(3)}},
+		{"[...0..<3]", []any{int64(0), int64(1), int64(2)}},
+		{"[...0..<0]", []any{}},
+		{"[...0..10 step 4]", []any{int64(0), int64(4), int64(8)}},
+		{"[...10..0 step -5]", []any{int64(10), int64(5), int64(0)}},
+		{"[...3..1]", []any{int64(3), int64(2), int64(1)}},
+		{"let n = 3\n[...0..<n - 1]", []any{int64(0), int64(1)}},
+		{"let a = [10, 20, 30, 40, 50]\n[a[1..3], a[0..<2], a[0..4 step 2]]", []any{
+			[]any{int64(20), int64(30), int64(40)}, []any{int64(10), int64(20)}, []any{int64(10), int64(30), int64(50)},
+		}},
+		{"\"你好世界\"[0..<2]", "你好"},
+		{"[5 in 1..10, 10 in 1..10, 10 in 1..<10, 4 in 0..10 step 2, 5 in 0..10 step 2]", []any{true, true, false, true, false}},
+		{"let lo = -9223372036854775807 - 1\nlet hi = 9223372036854775807\n[hi in lo..hi, lo in lo..hi, hi in lo..<hi, lo in hi..lo, 0 in lo..hi step 2, 1 in lo..hi step 2]", []any{true, true, false, true, true, false}},
+	})
+}
+
+// TestRangeLen 测试跨越整个 int64 范围的区间长度不溢出
+func TestRangeLen(t *testing.T) {
+	tests := []struct {
+		start, end, step int64
+		inclusive        bool
+		want             int64
+	}{
+		{math.MinInt64, math.MaxInt64, 1, true, math.MaxInt64},
+		{math.MinInt64, math.MaxInt64, 2, true, math.MaxInt64},
+		{math.MinInt64, math.MaxInt64, 4, true, 1 << 62},
+		{math.MinInt64, math.MaxInt64, math.MaxInt64, true, 3},
+		{math.MaxInt64, math.MinInt64, math.MinInt64, true, 2},
+		{math.MaxInt64, math.MinInt64, -1, false, math.MaxInt64},
+		{0, math.MaxInt64, 1, false, math.MaxInt64},
+		{5, 5, 1, false, 0},
+		{5, 1, 1, true, 0},
+	}
+	for _, tt := range tests {
+		r, err := ranges.New(tt.start, tt.end, tt.step, tt.inclusive, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Len(); got != tt.want {
+			t.Errorf("%s: len = %d, want %d", r, got, tt.want)
+		}
+	}
+}
@@ -25,6 +25,9 @@ func init() {
 func executeCodeForModule(filename string, code string, wk env.Workspace) (any, error) {
 	lex := lexer.New(filename, code)
 	lex.Parse()
+	if err := lex.Err(); err != nil {
+		return nil, err
+	}
 
 	p := parser.CreateParser(lex)
 
@@ -266,6 +269,10 @@ func (r *REPL) execute(code string) {
 	// 词法分析
 	lex := lexer.New("<repl>", code)
 	lex.Parse()
+	if err := lex.Err(); err != nil {
+		r.handleError(err)
+		return
+	}
 
 	// 语法分析
 	p := parser.CreateParser(lex)
@@ -288,6 +295,10 @@ func (r *REPL) execute(code string) {
 // handleError 处理错误
 func (r *REPL) handleError(rec interface{}) {
 	switch err := rec.(type) {
+	case verror.ErrorList:
+		for _, e := range err {
+			fmt.Println(e.Error())
+		}
 	case verror.VError:
 		fmt.Println(err.Error())
 	case verror.ParseVError:
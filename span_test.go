@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"vine-lang/ast"
+	"vine-lang/lexer"
+	"vine-lang/parser"
+	"vine-lang/verror"
+)
+
+// TestErrorPositions 测试解析与运行时错误指向出错的表达式
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"let a = nil\nlet b = a.x\n", "[Line 2, Column 9]"},
+		{"fn f(x):\n    return x\nend\nlet y = f(1, 2)\n", "[Line 4, Column 9]"},
+		{"let g = fn(x): x end\nlet y =   g()\n", "[Line 2, Column 11]"},
+		{"let n = 1\nlet y = [1, n(2)]\n", "[Line 2, Column 13]"},
+		{"fn h():\n    break\nend\nh()\n", "[Line 2, Column 5]"},
+		{"let m = match 3: case 1: 2 end\n", "[Line 1, Column 9]"},
+		{"let q = [1, 2]\n  q[5] = 1\n", "[Line 2, Column 3]"},
+		{"let r = 1 + (2 *\n", "[Line 2, Column 1]"},
+	}
+	for _, tt := range tests {
+		err := runForError("span.vine", tt.code)
+		if err == nil {
+			t.Errorf("%q: expected error", tt.code)
+			continue
+		}
+		var list verror.ErrorList
+		if errors.As(err, &list) {
+			if pos := list[0].GetPosition(); pos.Filename != "span.vine" {
+				t.Errorf("%q: filename = %q, want span.vine", tt.code, pos.Filename)
+			}
+		}
+		if got := err.Error(); !strings.HasPrefix(got, "span.vine: ") || !strings.Contains(got, tt.want) {
+			t.Errorf("%q: error = %q, want span.vine: %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestNodeSpans 测试每个节点都记录了源码范围，且子节点位于父节点范围之内
+func TestNodeSpans(t *testing.T) {
+	code := "let a = [1, ...b]\nfn add(x, y = 2):\n    return -x + y * foo.bar[1]?.baz(1, k: 2)\nend # add\n"
+	lex := lexer.New("span.vine", code)
+	lex.Parse()
+	p := parser.CreateParser(lex)
+	program := p.ParseProgram()
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	var check func(node ast.Node, parent ast.Span)
+	check = func(node ast.Node, parent ast.Span) {
+		span := node.NodeSpan()
+		if span.IsZero() || span.File != "span.vine" {
+			t.Errorf("%s: missing span %+v", node.String(), span)
+			return
+		}
+		if span.Start.Offset < parent.Start.Offset || span.End.Offset > parent.End.Offset {
+			t.Errorf("%s: span %d-%d outside parent %d-%d", node.String(), span.Start.Offset, span.End.Offset, parent.Start.Offset, parent.End.Offset)
+		}
+		for _, child := range ast.Children(node) {
+			check(child, span)
+		}
+	}
+	check(program, program.Span)
+
+	// 成员访问与调用的范围覆盖完整的源码片段
+	ret := program.Body[1].(*ast.FunctionDecl).Body.Body[0]
+	span := ret.NodeSpan()
+	if got := code[span.Start.Offset:span.End.Offset]; got != "return -x + y * foo.bar[1]?.baz(1, k: 2)" {
+		t.Errorf("return span = %q", got)
+	}
+	if span.Start.Line != 3 || span.Start.Column != 5 {
+		t.Errorf("return start = %d:%d, want 3:5", span.Start.Line, span.Start.Column)
+	}
+}
@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+// TestSpread 测试数组、对象与调用参数中的展开
+func TestSpread(t *testing.T) {
+	checkValues(t, "spread.vine", []valueTest{
+		{"let a = [1, 2]\n[...a, ...[3], 4]", []any{int64(1), int64(2), int64(3), int64(4)}},
+		{"[0, ...1..3]", []any{int64(0), int64(1), int64(2), int64(3)}},
+		{"let d = {host: \"h\", port: 80}\nlet c = {...d, port: 8080}\n[c.host, c.port, d.port]", []any{"h", int64(8080), int64(80)}},
+		{"let d = {debug: false}\n{debug: true, ...d}.debug", false},
+		{"fn sum3(x, y, z): x + y + z end\n[sum3(...[1, 2, 3]), sum3(10, ...[20, 30])]", []any{int64(6), int64(60)}},
+		{"fn count(...items): items end\ncount(...[1, 2], ...[3])", []any{int64(1), int64(2), int64(3)}},
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+// TestStringEscapes 测试字符串中转义序列解码后的值
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{`"a\tb\n"`, "a\tb\n"},
+		{`"\x41\x7a"`, "Az"},
+		{`"\xFF" == "\u00ff"`, true},
+		{`"\xe9"`, "é"},
+		{`"\u4e2d\u{1F600}"`, "中😀"},
+		{`'it\'s' + "\"q\""`, `it's"q"`},
+		{"`\\${1}\\x41`", "${1}A"},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "string.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestStringQuotes 测试单引号字符串与不处理转义的三引号字符串
+func TestStringQuotes(t *testing.T) {
+	checkValues(t, "string.vine", []valueTest{
+		{`'single' + "double"`, "singledouble"},
+		{`'say "hi"' + "it's"`, `say "hi"it's`},
+		{"\"\"\"line1\n  line2 \\n\"\"\"", "line1\n  line2 \\n"},
+		{"'''it's ${raw}'''", "it's ${raw}"},
+	})
+}
+
+// TestStringErrors 测试未闭合的字符串与非法转义报告在出错的位置
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		code   string
+		line   int
+		column int
+		want   string
+	}{
+		{"let s = \"open\nlet t = 1\n", 1, 9, "unterminated string literal"},
+		{"let s = 'open", 1, 9, "unterminated string literal"},
+		{"let s = \"\"\"open\nmore\n", 1, 9, "unterminated multi-line string literal"},
+		{`let s = "a\qb"`, 1, 11, `invalid escape sequence: \q`},
+		{"let a = 1\nlet s = \"\\u12\"", 2, 10, `invalid escape sequence: \u requires 4 hex digits`},
+		{`let s = "\u{}"`, 1, 10, `invalid escape sequence: \u{...} requires 1 to 6 hex digits`},
+		{`let s = "\u{1234567}"`, 1, 10, `invalid escape sequence: \u{...} requires 1 to 6 hex digits`},
+		{`let s = "\u{110000}"`, 1, 10, "invalid unicode code point: U+110000"},
+		{`let s = "\uD800"`, 1, 10, "invalid unicode code point: U+D800"},
+		{`let s = "\x4"`, 1, 10, `invalid escape sequence: \x requires 2 hex digits`},
+		{`let s = "ok\xZZ"`, 1, 12, `invalid escape sequence: \x requires 2 hex digits`},
+		{"let s = `a\\q`", 1, 11, `invalid escape sequence: \q`},
+	}
+	for _, tt := range tests {
+		errs, _ := lexErrors("string.vine", tt.code)
+		if len(errs) != 1 {
+			t.Errorf("%q: got %d errors, want 1: %v", tt.code, len(errs), errs)
+			continue
+		}
+		e := errs[0]
+		if e.Filename != "string.vine" || e.Line != tt.line || e.Column != tt.column || e.Message != tt.want {
+			t.Errorf("%q: error = %s:%d:%d %q, want string.vine:%d:%d %q", tt.code, e.Filename, e.Line, e.Column, e.Message, tt.line, tt.column, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"vine-lang/ast"
+	"vine-lang/env"
+	"vine-lang/ipt"
+	"vine-lang/parser"
+)
+
+// repeatStmt 测试用的扩展语句 repeat n: ... end，循环体内 it 为当前次数
+type repeatStmt struct {
+	ast.BaseNode
+	Count ast.Expr
+	Body  *ast.BlockStmt
+}
+
+func (r *repeatStmt) String() string {
+	return fmt.Sprintf("RepeatStmt(%s, %s)", r.Count.String(), r.Body.String())
+}
+
+var registerRepeat = sync.OnceValue(func() ast.NodeType {
+	repeatType := ast.RegisterNodeType("RepeatStmt")
+	ipt.RegisterSyntax("repeat", repeatType, func(p *parser.Parser) ast.Stmt {
+		p.Advance() // skip 'repeat'
+		count := p.ParseExpression()
+		return &repeatStmt{BaseNode: ast.BaseNode{Type: repeatType}, Count: count, Body: p.ParseBlock()}
+	}, func(i *ipt.Interpreter, node ast.Node, e *env.Environment) (any, error) {
+		n := node.(*repeatStmt)
+		count, err := i.Eval(n.Count, e)
+		if err != nil {
+			return nil, err
+		}
+		times, ok := count.(int64)
+		if !ok {
+			return nil, fmt.Errorf("repeat count must be an integer, got %T", count)
+		}
+		for k := range times {
+			loopEnv := env.New(e.WorkSpace)
+			loopEnv.Link(e)
+			loopEnv.DefineFast("it", k)
+			if _, err := i.Eval(n.Body, loopEnv); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	return repeatType
+})
+
+// TestRegisterSyntax 测试通过扩展 API 注册的语句可以端到端解析与执行
+func TestRegisterSyntax(t *testing.T) {
+	repeatType := registerRepeat()
+	if name, ok := ast.CustomNodeName(repeatType); !ok || name != "RepeatStmt" {
+		t.Fatalf("CustomNodeName(%d) = %q, %v", repeatType, name, ok)
+	}
+
+	wk := env.Workspace{Root: ".", BasePath: "."}
+
+	res, err := executeCode("repeat.vine", "let total = 0\nrepeat 4:\n    total += it\nend\ntotal\n", wk)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != int64(6) {
+		t.Errorf("total = %v, want 6", res)
+	}
+
+	// 扩展关键字在其他语句中同样可用
+	res, err = executeCode("repeat.vine", "let n = 0\nfn run(times):\n    repeat times:\n        n++\n    end\nend\nrun(3)\nn\n", wk)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res != int64(3) {
+		t.Errorf("n = %v, want 3", res)
+	}
+
+	// 解析错误照常报告
+	if _, err := executeCode("repeat.vine", "repeat:\nend\n", wk); err == nil {
+		t.Error("expected parse error for repeat without count")
+	}
+
+	// 扩展关键字不能再作为标识符使用
+	if _, err := executeCode("repeat.vine", "let repeat = 1\n", wk); err == nil {
+		t.Error("expected parse error when using keyword as identifier")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+// TestTemplateLiterals 测试模板字符串的插值、嵌套与转义
+func TestTemplateLiterals(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"let name = \"vine\"\nlet n = 4\n`hello ${name}, ${n * 2} left`", "hello vine, 8 left"},
+		{"`outer ${`inner ${1 + 1}`}`", "outer inner 2"},
+		{"`${[1, 2][1]}${{a: 3}.a}`", "23"},
+		{"`${nil} ${true} ${1.5}`", "nil true 1.5"},
+		{"`a\\tb`", "a\tb"},
+		{"`multi\nline`", "multi\nline"},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "template.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
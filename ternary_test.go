@@ -0,0 +1,12 @@
+package main
+
+import "testing"
+
+// TestTernary 测试三元表达式的结合性与短路
+func TestTernary(t *testing.T) {
+	checkValues(t, "ternary.vine", []valueTest{
+		{"let n = 85\nn >= 90 ? \"A\" : n >= 80 ? \"B\" : \"C\"", "B"},
+		{"let a = nil\na != nil ? a.x : 0", int64(0)},
+		{"1 + 1 == 2 ? 1 + 2 : 3 + 4", int64(3)},
+	})
+}
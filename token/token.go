@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode"
 	"vine-lang/verror"
 )
 
@@ -12,10 +13,12 @@ type TokenType string
 const (
 	EOF TokenType = "EOF"
 
-	NEWLINE    TokenType = "NEWLINE"
-	WHITESPACE TokenType = "WHITESPACE"
-	ILLEGAL    TokenType = "ILLEGAL"
-	COMMENT    TokenType = "COMMENT"
+	NEWLINE       TokenType = "NEWLINE"
+	WHITESPACE    TokenType = "WHITESPACE"
+	ILLEGAL       TokenType = "ILLEGAL"
+	COMMENT       TokenType = "COMMENT"
+	BLOCK_COMMENT TokenType = "BLOCK_COMMENT" // #[ ... ]#
+	DOC_COMMENT   TokenType = "DOC_COMMENT"   // ## ...
 
 	// Identifiers and literals
 	IDENT  TokenType = "IDENT"
@@ -23,6 +26,12 @@ const (
 	FLOAT  TokenType = "FLOAT"
 	STRING TokenType = "STRING"
 
+	// Template literals: `head ${ middle } tail`
+	TEMPLATE        TokenType = "TEMPLATE" // 不含插值的完整模板字符串
+	TEMPLATE_HEAD   TokenType = "TEMPLATE_HEAD"
+	TEMPLATE_MIDDLE TokenType = "TEMPLATE_MIDDLE"
+	TEMPLATE_TAIL   TokenType = "TEMPLATE_TAIL"
+
 	// Operators
 	ASSIGN TokenType = "="
 	PLUS   TokenType = "+"
@@ -32,6 +41,17 @@ const (
 	DIV    TokenType = "/"
 	QUOTE  TokenType = "\""
 
+	// Arithmetic and bitwise operators
+	MOD     TokenType = "%"
+	POW     TokenType = "**"
+	INT_DIV TokenType = "//" // 向下取整除法
+	BIT_AND TokenType = "&"
+	BIT_OR  TokenType = "|"
+	BIT_XOR TokenType = "^"
+	BIT_NOT TokenType = "~"
+	SHL     TokenType = "<<"
+	SHR     TokenType = ">>"
+
 	// Logical operators
 	AND        TokenType = "AND"
 	OR         TokenType = "OR"
@@ -48,13 +68,21 @@ const (
 	DEC_EQ     TokenType = "-="
 	MUL_EQ     TokenType = "*="
 	DIV_EQ     TokenType = "/="
+	MOD_EQ     TokenType = "%="
+	POW_EQ     TokenType = "**="
+	INT_DIV_EQ TokenType = "//="
 
 	// Delimiters
 	COMMA     TokenType = ","
 	SEMICOLON TokenType = ";"
 	DOT       TokenType = "."
+	DOTDOT    TokenType = ".."  // 闭区间 a..b
+	ELLIPSIS  TokenType = "..." // 剩余参数与展开
+	DOTDOT_LT TokenType = "..<" // 半开区间 a..<b
 	COLON     TokenType = ":"
 	QUESTION  TokenType = "?"
+	OPT_CHAIN TokenType = "?." // 可选链
+	COALESCE  TokenType = "??" // 空值合并
 
 	LPAREN   TokenType = "("
 	RPAREN   TokenType = ")"
@@ -80,6 +108,7 @@ const (
 	TASK     TokenType = "TASK"
 	EXPOSE   TokenType = "EXPOSE"
 	TYPEOF   TokenType = "TYPEOF"
+	IS       TokenType = "IS"
 	TRUE     TokenType = "TRUE"
 	FALSE    TokenType = "FALSE"
 	NIL      TokenType = "NIL"
@@ -90,6 +119,10 @@ const (
 	WAIT     TokenType = "WAIT"
 	TO       TokenType = "TO"
 	CATCH    TokenType = "CATCH"
+	MATCH    TokenType = "MATCH"
+	WHILE    TokenType = "WHILE"
+	LOOP     TokenType = "LOOP"
+	TYPE     TokenType = "TYPE"
 
 	/* Inside Tag */
 	Module TokenType = "__Module_TAG__"
@@ -112,16 +145,24 @@ var Keywords = map[string]TokenType{
 	"task":     TASK,
 	"expose":   EXPOSE,
 	"typeof":   TYPEOF,
+	"is":       IS,
 	"true":     TRUE,
 	"false":    FALSE,
 	"nil":      NIL,
 	"end":      END,
 	"switch":   SWITCH,
+	"match":    MATCH,
+	"while":    WHILE,
+	"loop":     LOOP,
+	"type":     TYPE,
 	"default":  DEFAULT,
 	"case":     CASE,
 	"wait":     WAIT,
 	"to":       TO,
 	"catch":    CATCH,
+	"and":      AND,
+	"or":       OR,
+	"not":      NOT,
 }
 
 type Token struct {
@@ -129,9 +170,23 @@ type Token struct {
 	Value  string // 字符串原始值
 	Line   int    // 行号
 	Column int    // 列号
+	Offset int    // 起始位置的字节偏移
+	End    Pos    // 结束位置，指向最后一个字符之后
 	hash   uint64 // 缓存的哈希值，用于map key
 }
 
+// Pos 源码中的位置，行列从 1 开始，Offset 为从 0 开始的字节偏移
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// Pos 返回 token 的起始位置
+func (t Token) Pos() Pos {
+	return Pos{Line: t.Line, Column: t.Column, Offset: t.Offset}
+}
+
 func NewToken(t TokenType, v rune, col, line int) Token {
 	return Token{
 		Type:   t,
@@ -161,6 +216,11 @@ func (t Token) ToPosition(fname string) verror.Position {
 	return verror.Position{Filename: fname, Line: t.Line, Column: t.Column}
 }
 
+// IsComment 判断是否为注释类 token
+func (t Token) IsComment() bool {
+	return t.Type == COMMENT || t.Type == BLOCK_COMMENT || t.Type == DOC_COMMENT
+}
+
 func (t Token) IsEmpty() bool {
 	return t == (Token{})
 }
@@ -169,17 +229,24 @@ func (t *Token) GetInt() (int64, error) {
 	if t.Type != INT {
 		return 0, fmt.Errorf("token is not INT type")
 	}
-	i, err := strconv.ParseInt(t.Value, 10, 64)
-	if err != nil {
-		return 0, err
+	if hasBasePrefix(t.Value) {
+		// 0x/0o/0b 前缀交给 strconv 按前缀识别进制，同时允许 '_' 分隔
+		return strconv.ParseInt(t.Value, 0, 64)
 	}
-	return i, nil
+	// 十进制整数不按前导 0 识别为八进制
+	if !validUnderscores(t.Value) {
+		return 0, fmt.Errorf("invalid digit separator in %q", t.Value)
+	}
+	return strconv.ParseInt(strings.ReplaceAll(t.Value, "_", ""), 10, 64)
 }
 
 func (t *Token) GetFloat() (float64, error) {
 	if t.Type != FLOAT {
 		return 0, fmt.Errorf("token is not FLOAT type")
 	}
+	if hasBasePrefix(t.Value) {
+		return 0, fmt.Errorf("invalid float literal %q", t.Value)
+	}
 	f, err := strconv.ParseFloat(t.Value, 64)
 	if err != nil {
 		return 0, err
@@ -187,6 +254,51 @@ func (t *Token) GetFloat() (float64, error) {
 	return f, nil
 }
 
+func hasBasePrefix(s string) bool {
+	return len(s) > 1 && s[0] == '0' && strings.ContainsRune("xXoObB", rune(s[1]))
+}
+
+// validUnderscores 检查 '_' 是否只出现在两个数字之间
+func validUnderscores(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(s)-1 || !isASCIIDigit(s[i-1]) || !isASCIIDigit(s[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isASCIIDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// RegisterKeyword 注册一个扩展关键字并返回其 TokenType
+// 扩展关键字的 TokenType 即小写的关键字本身，不会与内置类型冲突；
+// 应在初始化阶段调用，关键字不是合法标识符或已存在时 panic
+func RegisterKeyword(word string) TokenType {
+	word = strings.ToLower(word)
+	if !isIdentifier(word) {
+		panic(fmt.Sprintf("token: invalid keyword %q", word))
+	}
+	if _, exists := Keywords[word]; exists {
+		panic(fmt.Sprintf("token: keyword %q already registered", word))
+	}
+	Keywords[word] = TokenType(word)
+	return Keywords[word]
+}
+
+func isIdentifier(word string) bool {
+	for i, ch := range word {
+		if ch != '_' && !unicode.IsLetter(ch) && (i == 0 || !unicode.IsDigit(ch)) {
+			return false
+		}
+	}
+	return word != ""
+}
+
 func LookupIdent(ident string) TokenType {
 	if tok, ok := Keywords[strings.ToLower(ident)]; ok {
 		return tok
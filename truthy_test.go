@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// TestTruthiness 测试条件的真假：只有 nil 与 false 为假，0、"" 与空数组为真
+func TestTruthiness(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"if 0: \"yes\" else: \"no\" end", "yes"},
+		{"if \"\": \"yes\" else: \"no\" end", "yes"},
+		{"if []: \"yes\" else: \"no\" end", "yes"},
+		{"if {}: \"yes\" else: \"no\" end", "yes"},
+		{"if nil: \"yes\" else: \"no\" end", "no"},
+		{"if false: \"yes\" else: \"no\" end", "no"},
+		{"let a = {}\nif a?.b?.c: \"yes\" else: \"no\" end", "no"},
+		{"0 ? \"yes\" : \"no\"", "yes"},
+		{"nil ? \"yes\" : \"no\"", "no"},
+		{"0 and \"right\"", "right"},
+		{"nil and \"right\"", nil},
+		{"false or 0", int64(0)},
+		{"not 0", false},
+		{"not nil", true},
+		{"let n = 0\nlet items = [1, nil, 2]\nwhile items[n]:\n    n++\nend\nn", int64(1)},
+		{"let x = nil\nif x != nil and x.a > 1: 1 else: 2 end", int64(2)},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "truthy.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
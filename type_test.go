@@ -0,0 +1,144 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"vine-lang/libs/global"
+	"vine-lang/object/store"
+)
+
+const shapes = `
+type Shape:
+    let name = "shape"
+    let meta = {hits: 0}
+
+    fn init(name):
+        self.name = name
+    end
+
+    fn area():
+        0
+    end
+
+    fn describe():
+        ` + "`${self.name}: ${self.area()}`" + `
+    end
+end
+
+type Rect(Shape):
+    let w = 0
+    let h = 0
+
+    fn init(w, h):
+        super.init("rect")
+        self.w = w
+        self.h = h
+    end
+
+    fn area():
+        self.w * self.h
+    end
+
+    fn scale(k):
+        self.w *= k
+        self.h *= k
+        self
+    end
+end
+
+type Square(Rect):
+    fn init(size):
+        super.init(size, size)
+        self.name = "square"
+    end
+end
+`
+
+// TestTypeDecl 测试字段、构造、方法与单继承
+func TestTypeDecl(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"Rect(2, 3).area()", int64(6)},
+		{"Rect(2, 3).scale(2).area()", int64(24)},
+		{"Square(3).describe()", "square: 9"},
+		{"Shape(\"dot\").describe()", "dot: 0"},
+		{"let s = Square(2)\nlet f = s.area\nf()", int64(4)},
+		{"let a = Shape(\"a\")\nlet b = Shape(\"b\")\na.meta.hits = 1\nb.meta.hits", int64(0)},
+		{"let s = Square(1)\n[s is Square, s is Rect, s is Shape, s is object]", []any{true, true, true, true}},
+		{"[Rect(1, 1) is Square, {} is Shape, 1 is Shape, typeof Shape]", []any{false, false, false, "type"}},
+		{"match Square(1):\n    case typeof Rect: \"rect\"\n    case _: \"other\"\nend", "rect"},
+		{"let {w, h} = Rect(4, 5)\nw + h", int64(9)},
+		{"\"area\" in Square(1)", true},
+		{"type P:\n    let x = 1\n    let y = 2\nend\nlet p = P(y: 5)\n[p.x, p.y]", []any{int64(1), int64(5)}},
+		{"type C:\n    let n = 0\n    fn inc():\n        self.n++\n        self\n    end\nend\nC().inc().inc().n", int64(2)},
+	}
+	for _, tt := range tests {
+		code := shapes + tt.code
+		got := evalWith(t, "type.vine", code, nil)
+		if list, ok := tt.want.([]any); ok {
+			if arr, ok := got.([]any); !ok || !slices.Equal(arr, list) {
+				t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+			}
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestTypePrototype 测试实例只持有字段，方法沿原型链查找，打印时带有类型名
+func TestTypePrototype(t *testing.T) {
+	obj, ok := evalWith(t, "type.vine", shapes+"Square(2)", nil).(*store.StoreObject)
+	if !ok {
+		t.Fatal("expected an object")
+	}
+	if keys := obj.Keys(); !slices.Equal(keys, []string{"name", "meta", "w", "h"}) {
+		t.Errorf("keys = %v", keys)
+	}
+	depth := 0
+	for proto := obj.Parent(); proto != nil; proto = proto.Parent() {
+		depth++
+	}
+	if depth != 3 {
+		t.Errorf("prototype chain length = %d, want 3", depth)
+	}
+	if name := obj.TypeName(); name != "Square" {
+		t.Errorf("type name = %q", name)
+	}
+	if s := global.ToString(nil, obj); !strings.HasPrefix(s, "Square {") || strings.Contains(s, "area") {
+		t.Errorf("print = %q", s)
+	}
+	if s := global.ToString(nil, evalWith(t, "type.vine", shapes+"Rect", nil)); s != "<type Rect>" {
+		t.Errorf("print type = %q", s)
+	}
+	if s := global.ToString(nil, evalWith(t, "type.vine", "{type: 1, if: 2}", nil)); strings.Contains(s, "__proto__") || !strings.Contains(s, `"type": 1`) {
+		t.Errorf("print object = %q", s)
+	}
+}
+
+// TestTypeErrors 测试类型声明与构造的错误
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"type A:\n    let x = 1\nend\nA(1)\n", "type A has no init method"},
+		{"type A:\n    let x = 1\nend\nA(y: 1)\n", "unknown field y in call to A"},
+		{"let B = 1\ntype A(B):\nend\n", "cannot inherit from int"},
+		{"type A:\n    let x = 1\n    fn x(): 1 end\nend\n", "x is already declared in type A"},
+		{"type A:\n    print(1)\nend\n", "unexpected print in type body"},
+		{"type A:\n    fn f(): self.y end\nend\nA().f()\n", "property y not found"},
+		{"type A:\n    fn init(x): x end\nend\nA()\n", "missing argument for parameter x in call to init"},
+	}
+	for _, tt := range tests {
+		err := runForError("type.vine", tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestTypeof 测试脚本中各类值的类型名
+func TestTypeof(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"typeof 1", "int"},
+		{"typeof 1.5", "float"},
+		{"typeof `a${1}`", "string"},
+		{"typeof (1 < 2)", "bool"},
+		{"typeof nil", "nil"},
+		{"typeof [1, 2]", "array"},
+		{"typeof {a: 1}", "object"},
+		{"typeof fn(): 1 end", "function"},
+		{"use glb pick print\ntypeof print", "function"},
+		{"use glb\ntypeof glb", "module"},
+		{"use time\ntypeof time.Now()", "int"},
+		{"task fn t(): 1 end\ntypeof t()", "task"},
+		{"typeof (1..3)", "range"},
+		{"typeof typeof 1", "string"},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "typeof.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%q = %v, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+// TestTypeofNative 测试库模块返回的 Go 原生值与脚本中的同类值类型名一致
+func TestTypeofNative(t *testing.T) {
+	tests := []struct {
+		val  any
+		want string
+	}{
+		{int32(1), "int"},
+		{uint8(1), "int"},
+		{float32(1), "float"},
+		{[]string{"a"}, "array"},
+		{map[string]int{"a": 1}, "object"},
+		{struct{ A int }{1}, "object"},
+		{errors.New("boom"), "error"},
+		{func() {}, "function"},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "typeof.vine", "typeof v", map[string]any{"v": tt.val}); got != tt.want {
+			t.Errorf("typeof %T = %v, want %s", tt.val, got, tt.want)
+		}
+		if got := evalWith(t, "typeof.vine", "v is "+tt.want, map[string]any{"v": tt.val}); got != true {
+			t.Errorf("%T is %s = %v, want true", tt.val, tt.want, got)
+		}
+	}
+}
+
+// TestIs 测试 is 的优先级与未知类型名
+func TestIs(t *testing.T) {
+	tests := []struct {
+		code string
+		want any
+	}{
+		{"1 is int", true},
+		{"1 is float", false},
+		{"nil is nil", true},
+		{"not 1 is string", true},
+		{"[1] is array and {} is object", true},
+		{"let a = 1\na + 1 is int == true", true},
+	}
+	for _, tt := range tests {
+		if got := evalWith(t, "typeof.vine", tt.code, nil); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+
+	err := runForError("typeof.vine", "let a = 1\nlet b = a is integer\n")
+	if err == nil || !strings.Contains(err.Error(), `unknown type name "integer"`) || !strings.Contains(err.Error(), "[Line 2, Column 14]") {
+		t.Errorf("unknown type name error = %v", err)
+	}
+}
+
+// TestErrorTypeNames 测试错误信息中使用脚本中的类型名而不是 Go 类型
+func TestErrorTypeNames(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"let [a, b] = 1\n", "cannot destructure int as array"},
+		{"let {a} = [1]\n", "cannot destructure array as object"},
+		{"for x in 1:\nend\n", "cannot iterate over int"},
+		{"let a = [...1]\n", "cannot spread int as a list"},
+		{"let a = {...\"s\"}\n", "cannot spread string into object"},
+		{"let a = 1 in 2\n", "unsupported right operand for 'in': int"},
+		{"let a = 1 in \"abc\"\n", "must be a string, got int"},
+		{"let a = 1..\"b\"\n", "range bounds must be integers, got string"},
+		{"let a = ~1.5\n", "non-integer type float"},
+		{"let a = -\"s\"\n", "non-numeric type string"},
+	}
+	for _, tt := range tests {
+		err := runForError("typeof.vine", tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: error = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"vine-lang/ast"
+	"vine-lang/object/store"
 	"vine-lang/token"
 	"vine-lang/verror"
 )
@@ -90,10 +91,54 @@ type FunctionLikeValNode struct {
 	Token    *token.Token
 	Args     *ast.ArgsExpr
 	Body     *ast.BlockStmt
-	IsLamda  bool // 是否是匿名函数
-	IsModule bool // 是否是模块
-	IsInside bool // 是否是模块内部函数
-	IsTask   bool // 是否是协程函数
+	IsLamda  bool         // 是否是匿名函数
+	IsModule bool         // 是否是模块
+	IsInside bool         // 是否是模块内部函数
+	IsTask   bool         // 是否是协程函数
+	Owner    *TypeValNode // 声明方法的类型，普通函数为 nil
+	Self     any          // 方法绑定的实例
+}
+
+// Bind 返回绑定到实例 self 的方法
+func (f *FunctionLikeValNode) Bind(self any) *FunctionLikeValNode {
+	bound := *f
+	bound.Self = self
+	return &bound
+}
+
+// 用户定义的类型，方法存放在原型上，实例与子类型的原型通过 parent 连接到该原型
+type TypeValNode struct {
+	Val
+	Name   string
+	Super  *TypeValNode        // 父类型，可能为 nil
+	Proto  *store.StoreObject  // 原型
+	Fields []*ast.VariableDecl // 字段与默认值，每次构造实例时求值
+}
+
+func NewTypeValNode(name string, super *TypeValNode) *TypeValNode {
+	var parent *store.StoreObject
+	if super != nil {
+		parent = super.Proto
+	}
+	return &TypeValNode{
+		Name:  name,
+		Super: super,
+		Proto: store.NewPrototype(name, parent),
+	}
+}
+
+// IsInstance 判断 val 是否为该类型或其子类型的实例
+func (t *TypeValNode) IsInstance(val any) bool {
+	obj, ok := val.(*store.StoreObject)
+	if !ok {
+		return false
+	}
+	for proto := obj.Parent(); proto != nil; proto = proto.Parent() {
+		if proto == t.Proto {
+			return true
+		}
+	}
+	return false
 }
 
 // 任务
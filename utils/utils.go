@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"unicode"
@@ -198,6 +199,32 @@ func binaryIntegers(left int64, op token.TokenType, right int64) (any, error) {
 			return left / right, nil
 		}
 		return float64(left) / float64(right), nil
+	case token.INT_DIV:
+		if right == 0 {
+			return false, fmt.Errorf("division by zero")
+		}
+		return FloorDivInt(left, right), nil
+	case token.MOD:
+		if right == 0 {
+			return false, fmt.Errorf("modulo by zero")
+		}
+		return FloorModInt(left, right), nil
+	case token.POW:
+		return PowInt(left, right), nil
+	case token.BIT_AND:
+		return left & right, nil
+	case token.BIT_OR:
+		return left | right, nil
+	case token.BIT_XOR:
+		return left ^ right, nil
+	case token.SHL, token.SHR:
+		if right < 0 {
+			return false, fmt.Errorf("negative shift count: %d", right)
+		}
+		if op == token.SHL {
+			return left << right, nil
+		}
+		return left >> right, nil
 	default:
 		return false, fmt.Errorf("invalid operator '%v' for numbers", op)
 	}
@@ -216,17 +243,88 @@ func binaryNumbers(left float64, op token.TokenType, right float64) (any, error)
 			return false, fmt.Errorf("division by zero")
 		}
 		return left / right, nil
+	case token.INT_DIV:
+		if right == 0 {
+			return false, fmt.Errorf("division by zero")
+		}
+		return math.Floor(left / right), nil
+	case token.MOD:
+		if right == 0 {
+			return false, fmt.Errorf("modulo by zero")
+		}
+		return FloorModFloat(left, right), nil
+	case token.POW:
+		return math.Pow(left, right), nil
 	default:
 		return false, fmt.Errorf("invalid operator '%v' for numbers", op)
 	}
 }
 
+// FloorDivInt 向下取整的整数除法，与 FloorModInt 满足 a == (a // b) * b + a % b
+func FloorDivInt(left, right int64) int64 {
+	q := left / right
+	if (left%right != 0) && ((left < 0) != (right < 0)) {
+		q--
+	}
+	return q
+}
+
+// FloorModInt 取模，结果与除数同号
+func FloorModInt(left, right int64) int64 {
+	m := left % right
+	if m != 0 && ((m < 0) != (right < 0)) {
+		m += right
+	}
+	return m
+}
+
+// FloorModFloat 浮点数取模，结果与除数同号
+func FloorModFloat(left, right float64) float64 {
+	m := math.Mod(left, right)
+	if m != 0 && ((m < 0) != (right < 0)) {
+		m += right
+	}
+	return m
+}
+
+// PowInt 整数幂运算，指数为负数或结果超出 int64 范围时返回浮点数
+func PowInt(base, exp int64) any {
+	if exp < 0 {
+		return math.Pow(float64(base), float64(exp))
+	}
+	var result int64 = 1
+	var ok bool
+	for b, e := base, exp; e > 0; e >>= 1 {
+		if e&1 == 1 {
+			if result, ok = mulInt(result, b); !ok {
+				return math.Pow(float64(base), float64(exp))
+			}
+		}
+		if e > 1 {
+			if b, ok = mulInt(b, b); !ok {
+				return math.Pow(float64(base), float64(exp))
+			}
+		}
+	}
+	return result
+}
+
+// mulInt 整数乘法，溢出时 ok 为 false
+func mulInt(a, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+	return c, true
+}
+
 func binaryStrings(left string, op token.TokenType, right string) (string, error) {
 	switch op {
 	case token.PLUS:
 		return left + right, nil
-	case token.MINUS, token.MUL, token.DIV:
-		return "", fmt.Errorf("invalid operator '%v' for strings", op)
 	default:
 		return "", fmt.Errorf("invalid operator '%v' for strings", op)
 	}
@@ -248,6 +346,14 @@ type InternalValue struct {
 }
 
 func CompareVal(leftVal any, op token.TokenType, rightVal any) (bool, error) {
+	// 相等比较适用于任意类型
+	switch op {
+	case token.EQ:
+		return EqualVal(leftVal, rightVal), nil
+	case token.NOT_EQ:
+		return !EqualVal(leftVal, rightVal), nil
+	}
+
 	left, err := ResolveValue(leftVal)
 	if err != nil {
 		return false, fmt.Errorf("left param error: %v", err)
@@ -283,6 +389,115 @@ func CompareVal(leftVal any, op token.TokenType, rightVal any) (bool, error) {
 	}
 }
 
+// IsTruthy 判断值的真假，只有 nil 与 false 为假
+func IsTruthy(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case token.Token:
+		return v.Type != token.NIL && v.Type != token.FALSE
+	case *token.Token:
+		return v != nil && v.Type != token.NIL && v.Type != token.FALSE
+	}
+	return true
+}
+
+// IsNil 判断值是否为 nil
+func IsNil(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case token.Token:
+		return v.Type == token.NIL
+	case *token.Token:
+		return v == nil || v.Type == token.NIL
+	}
+	return false
+}
+
+// Object 键值对象，对象按自身的键逐个比较值
+type Object interface {
+	Keys() []string
+	Get(name token.Token) (any, bool)
+	TypeName() string
+}
+
+// EqualVal 判断两个值是否相等，基础类型按值比较，数组与对象按元素递归比较
+// 其余类型（函数、类型、模块等）按引用比较，不同类型的值总是不相等
+func EqualVal(leftVal any, rightVal any) bool {
+	if IsNil(leftVal) || IsNil(rightVal) {
+		return IsNil(leftVal) && IsNil(rightVal)
+	}
+	switch l := leftVal.(type) {
+	case []any:
+		r, ok := rightVal.([]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for index := range l {
+			if !EqualVal(l[index], r[index]) {
+				return false
+			}
+		}
+		return true
+	case Object:
+		r, ok := rightVal.(Object)
+		if !ok {
+			return false
+		}
+		return equalObject(l, r)
+	}
+	left, errL := ResolveValue(leftVal)
+	right, errR := ResolveValue(rightVal)
+	if errL != nil || errR != nil {
+		return sameRef(leftVal, rightVal)
+	}
+	if left.Kind != right.Kind {
+		if (left.Kind == TypeInt64 && right.Kind == TypeFloat64) || (left.Kind == TypeFloat64 && right.Kind == TypeInt64) {
+			return toFloat64(left.Value) == toFloat64(right.Value)
+		}
+		return false
+	}
+	return left.Value == right.Value
+}
+
+// equalObject 两个对象的类型与键相同且每个键的值相等时相等，键的顺序不影响结果
+func equalObject(left, right Object) bool {
+	if left == right {
+		return true
+	}
+	if reflect.TypeOf(left) != reflect.TypeOf(right) || left.TypeName() != right.TypeName() {
+		return false
+	}
+	keys := left.Keys()
+	if len(keys) != len(right.Keys()) {
+		return false
+	}
+	for _, key := range keys {
+		tk := token.Token{Type: token.IDENT, Value: key}
+		lv, _ := left.Get(tk)
+		rv, ok := right.Get(tk)
+		if !ok || !EqualVal(lv, rv) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameRef 判断两个非基础类型的值是否为同一个值，不可比较的类型按深度比较
+func sameRef(left, right any) bool {
+	lt, rt := reflect.TypeOf(left), reflect.TypeOf(right)
+	if lt != rt {
+		return false
+	}
+	if lt.Comparable() {
+		return left == right
+	}
+	return reflect.DeepEqual(left, right)
+}
+
 func ResolveValue(val any) (InternalValue, error) {
 	switch v := val.(type) {
 	case *token.Token:
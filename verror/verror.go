@@ -2,6 +2,7 @@ package verror
 
 import (
 	"fmt"
+	"strings"
 )
 
 type Position struct {
@@ -52,9 +53,27 @@ type InterpreterVError struct {
 }
 
 func (e InterpreterVError) Error() string {
+	if e.Filename != "" {
+		return fmt.Sprintf("%s: [Line %d, Column %d] Interpreter Error: %s", e.Filename, e.Line, e.Column, e.Message)
+	}
 	return fmt.Sprintf("[Line %d, Column %d] Interpreter Error: %s", e.Line, e.Column, e.Message)
 }
 
 func (e *InterpreterVError) GetPosition() Position {
 	return e.Position
 }
+
+// ErrorList 错误列表，用于一次性报告多个错误
+type ErrorList []VError
+
+func (l ErrorList) Error() string {
+	var lines = make([]string, len(l))
+	for i, err := range l {
+		if fname := err.GetPosition().Filename; fname != "" {
+			lines[i] = fmt.Sprintf("%s: %s", fname, err.Error())
+		} else {
+			lines[i] = err.Error()
+		}
+	}
+	return strings.Join(lines, "\n")
+}